@@ -2,11 +2,12 @@
  * @Author: zengzh
  * @Date: 2023-01-06 16:30:53
  * @Last Modified by: zengzh
- * @Last Modified time: 2023-01-07 13:27:36
+ * @Last Modified time: 2026-10-24 16:20:45
  */
 package bptree
 
 import (
+	"cmp"
 	"fmt"
 	"io"
 	"sort"
@@ -14,44 +15,44 @@ import (
 	"sync"
 )
 
-type Item interface {
-	Less(than Item) bool
-}
-
 const (
 	DefaultFreeListSize = 12
 )
 
-var (
-	nilItems    = make(items, 16)
-	nilChildren = make(children, 16)
-)
+// LessFunc reports whether a sorts before b.
+type LessFunc[T any] func(a, b T) bool
+
+// Less returns the LessFunc of an ordered type.
+func Less[T cmp.Ordered]() LessFunc[T] {
+	return cmp.Less[T]
+}
 
-type FreeList struct {
+type FreeListG[T any] struct {
 	mu       sync.Mutex
-	freelist []*node
+	freelist []*node[T]
 }
 
-func NewFreeList(size int) *FreeList {
-	return &FreeList{
-		freelist: make([]*node, 0, size),
+func NewFreeListG[T any](size int) *FreeListG[T] {
+	return &FreeListG[T]{
+		freelist: make([]*node[T], 0, size),
 	}
 }
 
-func (f *FreeList) newNode() (n *node) {
+func (f *FreeListG[T]) newNode() (n *node[T]) {
 	f.mu.Lock()
 	index := len(f.freelist) - 1
 	if index < 0 {
 		f.mu.Unlock()
+		return new(node[T])
 	}
-	// n = f.freelist[index] why this
+	n = f.freelist[index]
 	f.freelist[index] = nil
 	f.freelist = f.freelist[:index]
 	f.mu.Unlock()
 	return
 }
 
-func (f *FreeList) freeNode(n *node) (out bool) {
+func (f *FreeListG[T]) freeNode(n *node[T]) (out bool) {
 	f.mu.Lock()
 	if len(f.freelist) < cap(f.freelist) {
 		f.freelist = append(f.freelist, n)
@@ -61,215 +62,286 @@ func (f *FreeList) freeNode(n *node) (out bool) {
 	return
 }
 
-type ItemIterator func(i Item) bool
+type ItemIteratorG[T any] func(item T) bool
 
-func New(degree int) *BTree {
-	return NewWithFreeList(degree, NewFreeList(DefaultFreeListSize))
+func NewG[T any](degree int, less LessFunc[T]) *BTreeG[T] {
+	return NewWithFreeListG(degree, less, NewFreeListG[T](DefaultFreeListSize))
 }
 
-func NewWithFreeList(degree int, f *FreeList) *BTree {
+// NewOrderedG returns a tree of an ordered type.
+func NewOrderedG[T cmp.Ordered](degree int) *BTreeG[T] {
+	return NewG(degree, Less[T]())
+}
+
+func NewWithFreeListG[T any](degree int, less LessFunc[T], f *FreeListG[T]) *BTreeG[T] {
 	if degree <= 1 {
 		panic("invalid degree")
 	}
-	return &BTree{
+	return &BTreeG[T]{
 		degree: degree,
-		cow:    &copyOnWriteContext{freelist: f},
+		cow:    &copyOnWriteContext[T]{freelist: f, less: less},
 	}
 }
 
-type items []Item
+type items[T any] []T
 
-func (s *items) insertAt(index int, item Item) {
-	*s = append(*s, nil)
+func (s *items[T]) insertAt(index int, item T) {
+	var zero T
+	*s = append(*s, zero)
 	if index < len(*s) {
 		copy((*s)[index+1:], (*s)[index:])
 	}
 	(*s)[index] = item
 }
 
-func (s *items) removeAt(index int) Item {
+func (s *items[T]) removeAt(index int) T {
+	var zero T
 	item := (*s)[index]
 	copy((*s)[index:], (*s)[index+1:])
-	(*s)[len(*s)-1] = nil
+	(*s)[len(*s)-1] = zero
 	*s = (*s)[:len(*s)-1]
 	return item
 }
 
-func (s *items) pop() (out Item) {
+func (s *items[T]) pop() (out T) {
+	var zero T
 	index := len(*s) - 1
 	out = (*s)[index]
-	(*s)[index] = nil
+	(*s)[index] = zero
 	*s = (*s)[:index]
 	return
 }
 
-func (s *items) truncate(index int) {
-	var toClear items
+func (s *items[T]) truncate(index int) {
+	var toClear items[T]
 	*s, toClear = (*s)[:index], (*s)[index:]
-	for len(toClear) > 0 {
-		toClear = toClear[copy(toClear, nilItems):]
-	}
+	clear(toClear)
 }
 
-func (s items) find(item Item) (index int, found bool) {
-	i := sort.Search(len(s), func(i int) bool {
-		return item.Less(s[i])
+// childIndex returns the child whose subtree may hold item, an item equal to
+// a separator is on its right.
+func (s items[T]) childIndex(item T, less LessFunc[T]) int {
+	return sort.Search(len(s), func(i int) bool {
+		return less(item, s[i])
 	})
-	if i > 0 && !s[i-1].Less(item) {
+}
+
+func (s items[T]) find(item T, less LessFunc[T]) (index int, found bool) {
+	i := s.childIndex(item, less)
+	if i > 0 && !less(s[i-1], item) {
 		return i - 1, true
 	}
 	return i, false
 }
 
-type children []*node
-
-func (s *children) insertAt(index int, n *node) {
-	*s = append(*s, nil)
-	if index < len(*s) {
-		copy((*s)[index+1:], (*s)[index:])
+// nodeBody is the content of a node of both the in memory and the disk
+// trees, with the split, steal and merge steps they share. A leaf has no
+// children, internal nodes hold separators in items: children[i] holds the
+// keys less than items[i], children[i+1] those greater or equal. The leaves
+// of the disk tree keep their values beside the keys, those of the in memory
+// tree have none.
+//
+// The leaf links are left to the trees, the in memory tree links nodes it
+// owns and the disk tree has to load the neighbour pages.
+type nodeBody[K, V, C any] struct {
+	items    items[K]
+	values   items[V]
+	children items[C]
+}
+
+func (b *nodeBody[K, V, C]) leaf() bool {
+	return len(b.children) == 0
+}
+
+// splitInto moves the items from i into the empty node next, and returns the
+// separator between them. A leaf keeps a copy of it in next, an internal node
+// gives it up to the parent.
+func (b *nodeBody[K, V, C]) splitInto(i int, next *nodeBody[K, V, C]) K {
+	if b.leaf() {
+		next.items = append(next.items, b.items[i:]...)
+		b.items.truncate(i)
+		if len(b.values) > 0 {
+			next.values = append(next.values, b.values[i:]...)
+			b.values.truncate(i)
+		}
+		return next.items[0]
 	}
-	(*s)[index] = n
+	item := b.items[i]
+	next.items = append(next.items, b.items[i+1:]...)
+	b.items.truncate(i)
+	next.children = append(next.children, b.children[i+1:]...)
+	b.children.truncate(i + 1)
+	return item
 }
 
-func (s *children) removeAt(index int) (out *node) {
-	n := (*s)[index]
-	copy((*s)[index:], (*s)[index+1:])
-	(*s)[len(*s)-1] = nil
-	*s = (*s)[:len(*s)-1]
-	return n
+// stealLeft moves the last item of left, child i-1 of b, to the front of
+// child, child i of b.
+func (b *nodeBody[K, V, C]) stealLeft(i int, child, left *nodeBody[K, V, C]) {
+	if child.leaf() {
+		child.items.insertAt(0, left.items.pop())
+		if len(left.values) > 0 {
+			child.values.insertAt(0, left.values.pop())
+		}
+		b.items[i-1] = child.items[0]
+		return
+	}
+	child.items.insertAt(0, b.items[i-1])
+	b.items[i-1] = left.items.pop()
+	child.children.insertAt(0, left.children.pop())
 }
 
-func (s *children) pop() (out *node) {
-	index := len(*s) - 1
-	out = (*s)[index]
-	(*s)[index] = nil
-	*s = (*s)[:index]
-	return
+// stealRight moves the first item of right, child i+1 of b, to the end of
+// child, child i of b.
+func (b *nodeBody[K, V, C]) stealRight(i int, child, right *nodeBody[K, V, C]) {
+	if child.leaf() {
+		child.items = append(child.items, right.items.removeAt(0))
+		if len(right.values) > 0 {
+			child.values = append(child.values, right.values.removeAt(0))
+		}
+		b.items[i] = right.items[0]
+		return
+	}
+	child.items = append(child.items, b.items[i])
+	b.items[i] = right.items.removeAt(0)
+	child.children = append(child.children, right.children.removeAt(0))
 }
 
-func (s *children) truncate(index int) {
-	var toClear children
-	*s, toClear = (*s)[:index], (*s)[index:]
-	for len(toClear) > 0 {
-		toClear = toClear[copy(toClear, nilChildren):]
+// merge moves right, child i+1 of b, into left, child i of b, and removes it
+// from b.
+func (b *nodeBody[K, V, C]) merge(i int, left, right *nodeBody[K, V, C]) {
+	item := b.items.removeAt(i)
+	b.children.removeAt(i + 1)
+	if !left.leaf() {
+		left.items = append(left.items, item)
+		left.children = append(left.children, right.children...)
 	}
+	left.items = append(left.items, right.items...)
+	left.values = append(left.values, right.values...)
 }
 
-type node struct {
-	items    items
-	children children
-	cow      *copyOnWriteContext
+// node is a node of the in memory tree, its leaves hold the items and are
+// linked in order.
+//
+// The links belong to the copy on write context like the rest of the node: a
+// link between two leaves owned by a tree is right, other links may point to
+// leaves a clone replaced since, see nextLeaf.
+type node[T any] struct {
+	nodeBody[T, struct{}, *node[T]]
+	prev, next *node[T]
+	cow        *copyOnWriteContext[T]
 }
 
-func (n *node) mutableFor(cow *copyOnWriteContext) *node {
+// mutableFor returns n if cow owns it, or a copy owned by cow. A copied leaf
+// is not linked yet.
+func (n *node[T]) mutableFor(cow *copyOnWriteContext[T]) *node[T] {
 	if n.cow == cow {
 		return n
 	}
 	out := cow.newNode()
-	if cap(out.items) >= len(n.items) {
-		out.items = out.items[:len(n.items)]
-	} else {
-		out.items = make(items, len(n.items), cap(n.items))
-	}
-	copy(out.items, n.items)
-	if cap(out.children) >= len(n.children) {
-		out.children = out.children[:len(n.children)]
-	} else {
-		out.children = make(children, len(n.children), cap(n.children))
-	}
-	copy(out.children, n.children)
+	out.items = append(out.items, n.items...)
+	out.children = append(out.children, n.children...)
 	return out
 }
 
-func (n *node) mutableChild(i int) *node {
-	c := n.children[i].mutableFor(n.cow)
+// mutableChild makes child i owned by n. A copied leaf is linked to the
+// siblings n owns, the leaves of other nodes are found by nextLeaf.
+func (n *node[T]) mutableChild(i int) *node[T] {
+	c := n.children[i]
+	if c.cow == n.cow {
+		return c
+	}
+	c = c.mutableFor(n.cow)
 	n.children[i] = c
+	if c.leaf() {
+		if i > 0 {
+			link(n.children[i-1], c)
+		}
+		if i+1 < len(n.children) {
+			link(c, n.children[i+1])
+		}
+	}
 	return c
 }
 
-func (n *node) split(i int) (Item, *node) {
-	item := n.items[i]
+// link links the leaves a and b if they are owned by the same tree, a shared
+// leaf is never written.
+func link[T any](a, b *node[T]) {
+	if a.cow == b.cow {
+		a.next, b.prev = b, a
+	}
+}
+
+// split moves the items from i into a new right node, and returns the
+// separator between them.
+func (n *node[T]) split(i int) (T, *node[T]) {
 	next := n.cow.newNode()
-	next.items = append(next.items, n.items[i+1:]...)
-	n.items.truncate(i)
-	if len(n.children) > 0 {
-		next.children = append(next.children, n.children[i+1:]...)
-		n.children.truncate(i + 1)
+	item := n.splitInto(i, &next.nodeBody)
+	if n.leaf() {
+		if n.next != nil {
+			link(next, n.next)
+		}
+		link(n, next)
 	}
 	return item, next
 }
 
-func (n *node) maybeSplitChild(i, maxItems int) bool {
+func (n *node[T]) maybeSplitChild(i, maxItems int) bool {
 	if len(n.children[i].items) < maxItems {
 		return false
 	}
-	first := n.mutableChild(i)
-	item, second := first.split(maxItems / 2)
+	item, second := n.mutableChild(i).split(maxItems / 2)
 	n.items.insertAt(i, item)
 	n.children.insertAt(i+1, second)
 	return true
 }
 
-func (n *node) insert(item Item, maxItems int) Item {
-	i, found := n.items.find(item)
+func (n *node[T]) insert(item T, maxItems int) (_ T, _ bool) {
+	less := n.cow.less
+	for !n.leaf() {
+		i := n.items.childIndex(item, less)
+		if n.maybeSplitChild(i, maxItems) && !less(item, n.items[i]) {
+			i++
+		}
+		n = n.mutableChild(i)
+	}
+	i, found := n.items.find(item, less)
 	if found {
 		out := n.items[i]
 		n.items[i] = item
-		return out
-	}
-	if len(n.children) == 0 {
-		n.items.insertAt(i, item)
-		return nil
-	}
-	if n.maybeSplitChild(i, maxItems) {
-		inTree := n.items[i]
-		switch {
-		case item.Less(inTree):
-		case inTree.Less(item):
-			i++
-		default:
-			out := n.items[i]
-			n.items[i] = item
-			return out
-		}
+		return out, true
 	}
-	return n.mutableChild(i).insert(item, maxItems)
+	n.items.insertAt(i, item)
+	return
 }
 
-func (n *node) get(key Item) Item {
-	i, found := n.items.find(key)
-	if found {
-		return n.items[i]
-	} else if len(n.children) > 0 {
-		return n.children[i].get(key)
+// findLeaf returns the leaf that may hold key.
+func (n *node[T]) findLeaf(key T) *node[T] {
+	for !n.leaf() {
+		n = n.children[n.items.childIndex(key, n.cow.less)]
 	}
-	return nil
+	return n
 }
 
-func min(n *node) Item {
-	if n == nil {
-		return nil
+func (n *node[T]) get(key T) (_ T, _ bool) {
+	n = n.findLeaf(key)
+	if i, found := n.items.find(key, n.cow.less); found {
+		return n.items[i], true
 	}
-	for len(n.children) > 0 {
+	return
+}
+
+func (n *node[T]) firstLeaf() *node[T] {
+	for !n.leaf() {
 		n = n.children[0]
 	}
-	if len(n.items) == 0 {
-		return nil
-	}
-	return n.items[0]
+	return n
 }
 
-func max(n *node) Item {
-	if n == nil {
-		return nil
-	}
-	for len(n.children) > 0 {
+func (n *node[T]) lastLeaf() *node[T] {
+	for !n.leaf() {
 		n = n.children[len(n.children)-1]
 	}
-	if len(n.items) == 0 {
-		return nil
-	}
-	return n.items[len(n.items)-1]
+	return n
 }
 
 type toRemove int
@@ -280,172 +352,218 @@ const (
 	removeMax
 )
 
-func (n *node) remove(item Item, minItems int, typ toRemove) Item {
-	var i int
-	var found bool
-	switch typ {
-	case removeMax:
-		if len(n.children) == 0 {
-			return n.items.pop()
-		}
-		i = len(n.items)
-	case removeMin:
-		if len(n.children) == 0 {
-			return n.items.removeAt(0)
+// remove removes an item from the subtree, growing every child on the way
+// down to more than minItems first so that it never underflows.
+func (n *node[T]) remove(item T, minItems int, typ toRemove) (_ T, _ bool) {
+	for !n.leaf() {
+		var i int
+		switch typ {
+		case removeMax:
+			i = len(n.children) - 1
+		case removeMin:
+			i = 0
+		case removeItem:
+			i = n.items.childIndex(item, n.cow.less)
+		default:
+			panic("invalid type")
 		}
-		i = 0
-	case removeItem:
-		i, found = n.items.find(item)
-		if len(n.children) == 0 {
-			if found {
-				return n.items.removeAt(i)
-			}
-			return nil
+		if len(n.children[i].items) <= minItems {
+			// the child may change, look again
+			n.growChild(i, minItems)
+			continue
 		}
-	default:
-		panic("invalid type")
+		n = n.mutableChild(i)
 	}
-	if len(n.children[i].items) <= minItems {
-		return n.growChildAndRemove(i, item, minItems, typ)
+	if len(n.items) == 0 {
+		return
 	}
-	child := n.mutableChild(i)
-	if found {
-		out := n.items[i]
-		n.items[i] = child.remove(nil, minItems, removeMax)
-		return out
+	switch typ {
+	case removeMax:
+		return n.items.pop(), true
+	case removeMin:
+		return n.items.removeAt(0), true
 	}
-	return child.remove(item, minItems, typ)
+	if i, found := n.items.find(item, n.cow.less); found {
+		return n.items.removeAt(i), true
+	}
+	return
 }
 
-func (n *node) growChildAndRemove(i int, item Item, minItems int, typ toRemove) Item {
+// growChild gives child i more than minItems items, by stealing one from a
+// sibling or merging with it.
+func (n *node[T]) growChild(i, minItems int) {
 	if i > 0 && len(n.children[i-1].items) > minItems {
 		child := n.mutableChild(i)
-		stealFrom := n.mutableChild(i - 1)
-		stolenItem := stealFrom.items.pop()
-		child.items.insertAt(0, n.items[i-1])
-		n.items[i-1] = stolenItem
-		if len(stealFrom.children) > 0 {
-			child.children.insertAt(0, stealFrom.children.pop())
-		}
-	} else if i < len(n.items) && len(n.children[i+1].items) > minItems {
+		n.stealLeft(i, &child.nodeBody, &n.mutableChild(i-1).nodeBody)
+		return
+	}
+	if i < len(n.items) && len(n.children[i+1].items) > minItems {
 		child := n.mutableChild(i)
-		stealFrom := n.mutableChild(i + 1)
-		stolenItem := stealFrom.items.removeAt(0)
-		child.items = append(child.items, n.items[i])
-		n.items[i] = stolenItem
-		if len(stealFrom.children) > 0 {
-			child.children = append(child.children, stealFrom.children.removeAt(0))
-		}
-	} else {
-		if i == len(n.items) {
-			i--
+		n.stealRight(i, &child.nodeBody, &n.mutableChild(i+1).nodeBody)
+		return
+	}
+	if i == len(n.items) {
+		i--
+	}
+	child := n.mutableChild(i)
+	// the merged child is only read, and freed if owned
+	mergeChild := n.children[i+1]
+	n.merge(i, &child.nodeBody, &mergeChild.nodeBody)
+	if child.leaf() {
+		child.next = nil
+		if mergeChild.next != nil {
+			link(child, mergeChild.next)
 		}
-		child := n.mutableChild(i)
-		mergeItem := n.items.removeAt(i)
-		mergeChild := n.children.removeAt(i + 1)
-		child.items = append(child.items, mergeItem)
-		child.items = append(child.items, mergeChild.items...)
-		child.children = append(child.children, mergeChild.children...)
-		n.cow.freeNode(mergeChild)
 	}
-	return n.remove(item, minItems, typ)
+	n.cow.freeNode(mergeChild)
 }
 
-type direction int
+// optional is a range bound, which is unbounded unless valid.
+type optional[T any] struct {
+	item  T
+	valid bool
+}
 
-const (
-	descend = direction(-1)
-	ascend  = direction(+1)
-)
+func bound[T any](item T) optional[T] {
+	return optional[T]{item: item, valid: true}
+}
 
-func (n *node) iterate(dir direction, start, stop Item, includeStart bool, hit bool, iter ItemIterator) (bool, bool) {
-	var ok, found bool
-	var index int
-	switch dir {
-	case ascend:
-		if start != nil {
-			index, _ = n.items.find(start)
+// ascend calls iter for the items from start up to stop, walking the leaves.
+func (t *BTreeG[T]) ascend(start, stop optional[T], includeStart bool, iter ItemIteratorG[T]) {
+	less := t.cow.less
+	var n *node[T]
+	var i int
+	if !start.valid {
+		n = t.root.firstLeaf()
+	} else {
+		n = t.root.findLeaf(start.item)
+		i = sort.Search(len(n.items), func(i int) bool {
+			return !less(n.items[i], start.item)
+		})
+		if !includeStart && i < len(n.items) && !less(start.item, n.items[i]) {
+			i++
 		}
-		for i := index; i < len(n.items); i++ {
-			if len(n.children) > 0 {
-				if hit, ok = n.children[i].iterate(dir, start, stop, includeStart, hit, iter); !ok {
-					return hit, false
-				}
-				if !includeStart && !hit && start != nil && !start.Less(n.items[i]) {
-					hit = true
-					continue
-				}
-				hit = true
-				if stop != nil && !n.items[i].Less(stop) {
-					return hit, false
-				}
-				if !iter(n.items[i]) {
-					return hit, false
-				}
+	}
+	for ; n != nil; n, i = t.nextLeaf(n), 0 {
+		for ; i < len(n.items); i++ {
+			if stop.valid && !less(n.items[i], stop.item) {
+				return
 			}
-		}
-		if len(n.children) > 0 {
-			if hit, ok = n.children[len(n.children)-1].iterate(dir, start, stop, includeStart, hit, iter); !ok {
-				return hit, false
+			if !iter(n.items[i]) {
+				return
 			}
 		}
-	case descend:
-		if start != nil {
-			index, found = n.items.find(start)
-			if !found {
-				index = index - 1
-			}
-		} else {
-			index = len(n.items) - 1
+	}
+}
+
+// descend calls iter for the items from start down to stop, walking the
+// leaves.
+func (t *BTreeG[T]) descend(start, stop optional[T], includeStart bool, iter ItemIteratorG[T]) {
+	less := t.cow.less
+	var n *node[T]
+	var i int
+	if !start.valid {
+		n = t.root.lastLeaf()
+		i = len(n.items) - 1
+	} else {
+		n = t.root.findLeaf(start.item)
+		i = n.items.childIndex(start.item, less) - 1
+		if !includeStart && i >= 0 && !less(n.items[i], start.item) {
+			i--
 		}
-		for i := index; i >= 0; i-- {
-			if start != nil && !n.items[i].Less(start) {
-				if !includeStart || hit || start.Less(n.items[i]) {
-					continue
-				}
-				if len(n.children) > 0 {
-					if hit, ok = n.children[i+1].iterate(dir, start, stop, includeStart, hit, iter); !ok {
-						return hit, false
-					}
-				}
-				if stop != nil && !stop.Less(n.items[i]) {
-					return hit, false
-				}
-				hit = true
-				if !iter(n.items[i]) {
-					return hit, false
-				}
+	}
+	for n != nil {
+		for ; i >= 0; i-- {
+			if stop.valid && !less(stop.item, n.items[i]) {
+				return
 			}
-		}
-		if len(n.children) > 0 {
-			if hit, ok = n.children[0].iterate(dir, start, stop, includeStart, hit, iter); !ok {
-				return hit, false
+			if !iter(n.items[i]) {
+				return
 			}
 		}
+		if n = t.prevLeaf(n); n != nil {
+			i = len(n.items) - 1
+		}
 	}
-	return hit, true
 }
 
-func (n *node) print(w io.Writer, level int) {
-	fmt.Fprint(w, "%sNODE:%v\n", strings.Repeat(" ", level), n.items)
+// nextLeaf returns the leaf after n. It follows the link of n if both leaves
+// are owned by the tree, otherwise the link may be one of a clone, and the
+// leaf is looked up from the root in O(log n).
+func (t *BTreeG[T]) nextLeaf(n *node[T]) *node[T] {
+	if n.cow == t.cow && n.next != nil && n.next.cow == t.cow {
+		return n.next
+	}
+	// only an emptied root is empty
+	if len(n.items) == 0 {
+		return nil
+	}
+	key := n.items[len(n.items)-1]
+	var next *node[T]
+	for m := t.root; !m.leaf(); {
+		i := m.items.childIndex(key, t.cow.less)
+		if i+1 < len(m.children) {
+			next = m.children[i+1]
+		}
+		m = m.children[i]
+	}
+	if next == nil {
+		return nil
+	}
+	return next.firstLeaf()
+}
+
+// prevLeaf returns the leaf before n, like nextLeaf.
+func (t *BTreeG[T]) prevLeaf(n *node[T]) *node[T] {
+	if n.cow == t.cow && n.prev != nil && n.prev.cow == t.cow {
+		return n.prev
+	}
+	if len(n.items) == 0 {
+		return nil
+	}
+	key := n.items[0]
+	var prev *node[T]
+	for m := t.root; !m.leaf(); {
+		i := m.items.childIndex(key, t.cow.less)
+		if i > 0 {
+			prev = m.children[i-1]
+		}
+		m = m.children[i]
+	}
+	if prev == nil {
+		return nil
+	}
+	return prev.lastLeaf()
+}
+
+func (n *node[T]) print(w io.Writer, level int) {
+	kind := "NODE"
+	if n.leaf() {
+		kind = "LEAF"
+	}
+	fmt.Fprintf(w, "%s%s:%v\n", strings.Repeat(" ", level), kind, n.items)
 	for _, c := range n.children {
 		c.print(w, level+1)
 	}
 }
 
-type BTree struct {
+// BTreeG is a B+ tree: the items are kept in linked leaves, so range scans
+// walk the leaves in order.
+type BTreeG[T any] struct {
 	degree int
 	length int
-	root   *node
-	cow    *copyOnWriteContext
+	root   *node[T]
+	cow    *copyOnWriteContext[T]
 }
 
-type copyOnWriteContext struct {
-	freelist *FreeList
+type copyOnWriteContext[T any] struct {
+	freelist *FreeListG[T]
+	less     LessFunc[T]
 }
 
-// copy on write
-func (t *BTree) Clone() (t2 *BTree) {
+// Clone returns a copy of the tree in O(1). The nodes are shared, and a tree
+// copies the path to the nodes it writes, with the leaves on it.
+func (t *BTreeG[T]) Clone() (t2 *BTreeG[T]) {
 	cow1, cow2 := *t.cow, *t.cow
 	out := *t
 	t.cow = &cow1
@@ -453,15 +571,15 @@ func (t *BTree) Clone() (t2 *BTree) {
 	return &out
 }
 
-func (t *BTree) maxItems() int {
+func (t *BTreeG[T]) maxItems() int {
 	return t.degree*2 - 1
 }
 
-func (t *BTree) minItems() int {
+func (t *BTreeG[T]) minItems() int {
 	return t.degree - 1
 }
 
-func (c *copyOnWriteContext) newNode() (n *node) {
+func (c *copyOnWriteContext[T]) newNode() (n *node[T]) {
 	n = c.freelist.newNode()
 	n.cow = c
 	return
@@ -476,10 +594,11 @@ const (
 	ftNotOwned
 )
 
-func (c *copyOnWriteContext) freeNode(n *node) freeType {
+func (c *copyOnWriteContext[T]) freeNode(n *node[T]) freeType {
 	if n.cow == c {
 		n.items.truncate(0)
 		n.children.truncate(0)
+		n.prev, n.next = nil, nil
 		n.cow = nil
 		if c.freelist.freeNode(n) {
 			return ftStored
@@ -491,148 +610,157 @@ func (c *copyOnWriteContext) freeNode(n *node) freeType {
 	}
 }
 
-func (t *BTree) ReplaceOrInsert(item Item) Item {
-	if item == nil {
-		panic("nil item being added to BTree")
-	}
+// ReplaceOrInsert adds the item, and returns the item it replaced if any.
+func (t *BTreeG[T]) ReplaceOrInsert(item T) (_ T, _ bool) {
 	if t.root == nil {
 		t.root = t.cow.newNode()
 		t.root.items = append(t.root.items, item)
 		t.length++
-		return nil
-	} else {
-		t.root = t.root.mutableFor(t.cow)
-		if len(t.root.items) >= t.maxItems() {
-			item2, second := t.root.split(t.maxItems() / 2)
-			oldroot := t.root
-			t.root = t.cow.newNode()
-			t.root.items = append(t.root.items, item2)
-			t.root.children = append(t.root.children, oldroot, second)
-		}
+		return
+	}
+	t.root = t.root.mutableFor(t.cow)
+	if len(t.root.items) >= t.maxItems() {
+		item2, second := t.root.split(t.maxItems() / 2)
+		oldroot := t.root
+		t.root = t.cow.newNode()
+		t.root.items = append(t.root.items, item2)
+		t.root.children = append(t.root.children, oldroot, second)
 	}
-	out := t.root.insert(item, t.maxItems())
-	if out == nil {
+	out, replaced := t.root.insert(item, t.maxItems())
+	if !replaced {
 		t.length++
 	}
-	return out
+	return out, replaced
 }
 
-func (t *BTree) Delete(item Item) Item {
+func (t *BTreeG[T]) Delete(item T) (T, bool) {
 	return t.deleteItem(item, removeItem)
 }
 
-func (t *BTree) DeleteMin() Item {
-	return t.deleteItem(nil, removeMin)
+func (t *BTreeG[T]) DeleteMin() (T, bool) {
+	var zero T
+	return t.deleteItem(zero, removeMin)
 }
 
-func (t *BTree) DeleteMax() Item {
-	return t.deleteItem(nil, removeMax)
+func (t *BTreeG[T]) DeleteMax() (T, bool) {
+	var zero T
+	return t.deleteItem(zero, removeMax)
 }
 
-func (t *BTree) deleteItem(item Item, typ toRemove) Item {
-	if t.root == nil || len(t.root.items) == 0 {
-		return nil
+func (t *BTreeG[T]) deleteItem(item T, typ toRemove) (_ T, _ bool) {
+	if t.length == 0 {
+		return
 	}
 	t.root = t.root.mutableFor(t.cow)
-	out := t.root.remove(item, t.minItems(), typ)
-	if len(t.root.items) == 0 && len(t.root.children) > 0 {
+	out, removed := t.root.remove(item, t.minItems(), typ)
+	if len(t.root.items) == 0 && !t.root.leaf() {
 		oldroot := t.root
 		t.root = t.root.children[0]
 		t.cow.freeNode(oldroot)
 	}
-	if out != nil {
+	if removed {
 		t.length--
 	}
-	return out
+	return out, removed
 }
 
-func (t *BTree) AscendRange(greaterOrEqual, lessThan Item, iterator ItemIterator) {
+func (t *BTreeG[T]) AscendRange(greaterOrEqual, lessThan T, iterator ItemIteratorG[T]) {
 	if t.root == nil {
 		return
 	}
-	t.root.iterate(ascend, greaterOrEqual, lessThan, true, false, iterator)
+	t.ascend(bound(greaterOrEqual), bound(lessThan), true, iterator)
 }
 
-func (t *BTree) AscendLessThan(pivot Item, iterator ItemIterator) {
+func (t *BTreeG[T]) AscendLessThan(pivot T, iterator ItemIteratorG[T]) {
 	if t.root == nil {
 		return
 	}
-	t.root.iterate(ascend, nil, pivot, false, false, iterator)
+	t.ascend(optional[T]{}, bound(pivot), false, iterator)
 }
 
-func (t *BTree) AscendGreaterOrEqual(pivot Item, iterator ItemIterator) {
+func (t *BTreeG[T]) AscendGreaterOrEqual(pivot T, iterator ItemIteratorG[T]) {
 	if t.root == nil {
 		return
 	}
-	t.root.iterate(ascend, pivot, nil, true, false, iterator)
+	t.ascend(bound(pivot), optional[T]{}, true, iterator)
 }
 
-func (t *BTree) Ascend(iterator ItemIterator) {
+func (t *BTreeG[T]) Ascend(iterator ItemIteratorG[T]) {
 	if t.root == nil {
 		return
 	}
-	t.root.iterate(ascend, nil, nil, false, false, iterator)
+	t.ascend(optional[T]{}, optional[T]{}, false, iterator)
 }
 
-func (t *BTree) DescendRange(lessOrEqual, greaterThan Item, iterator ItemIterator) {
+func (t *BTreeG[T]) DescendRange(lessOrEqual, greaterThan T, iterator ItemIteratorG[T]) {
 	if t.root == nil {
 		return
 	}
-	t.root.iterate(descend, lessOrEqual, greaterThan, true, false, iterator)
+	t.descend(bound(lessOrEqual), bound(greaterThan), true, iterator)
 }
 
-func (t *BTree) DescendLessOrEqual(pivot Item, iterator ItemIterator) {
+func (t *BTreeG[T]) DescendLessOrEqual(pivot T, iterator ItemIteratorG[T]) {
 	if t.root == nil {
 		return
 	}
-	t.root.iterate(descend, pivot, nil, true, false, iterator)
+	t.descend(bound(pivot), optional[T]{}, true, iterator)
 }
 
-func (t *BTree) DescendGreaterThan(pivot Item, iterator ItemIterator) {
+func (t *BTreeG[T]) DescendGreaterThan(pivot T, iterator ItemIteratorG[T]) {
 	if t.root == nil {
 		return
 	}
-	t.root.iterate(descend, nil, pivot, false, false, iterator)
+	t.descend(optional[T]{}, bound(pivot), false, iterator)
 }
 
-func (t *BTree) Descend(iterator ItemIterator) {
+func (t *BTreeG[T]) Descend(iterator ItemIteratorG[T]) {
 	if t.root == nil {
 		return
 	}
-	t.root.iterate(descend, nil, nil, false, false, iterator)
+	t.descend(optional[T]{}, optional[T]{}, false, iterator)
 }
 
-func (t *BTree) Get(key Item) Item {
+func (t *BTreeG[T]) Get(key T) (_ T, _ bool) {
 	if t.root == nil {
-		return nil
+		return
 	}
 	return t.root.get(key)
 }
 
-func (t *BTree) Min() Item {
-	return min(t.root)
+func (t *BTreeG[T]) Min() (_ T, _ bool) {
+	if t.length == 0 {
+		return
+	}
+	return t.root.firstLeaf().items[0], true
 }
 
-func (t *BTree) Max() Item {
-	return max(t.root)
+func (t *BTreeG[T]) Max() (_ T, _ bool) {
+	if t.length == 0 {
+		return
+	}
+	leaf := t.root.lastLeaf()
+	return leaf.items[len(leaf.items)-1], true
 }
 
-func (t *BTree) Has(key Item) bool {
-	return t.Get(key) != nil
+func (t *BTreeG[T]) Has(key T) bool {
+	_, ok := t.Get(key)
+	return ok
 }
 
-func (t *BTree) Len() int {
+func (t *BTreeG[T]) Len() int {
 	return t.length
 }
 
-func (t *BTree) Clear(addNodesForFreeList bool) {
+// Clear removes all the items, and gives the nodes only this tree uses to the
+// freelist if addNodesForFreeList is set.
+func (t *BTreeG[T]) Clear(addNodesForFreeList bool) {
 	if t.root != nil && addNodesForFreeList {
 		t.root.reset(t.cow)
 	}
 	t.root, t.length = nil, 0
 }
 
-func (n *node) reset(c *copyOnWriteContext) bool {
+func (n *node[T]) reset(c *copyOnWriteContext[T]) bool {
 	for _, child := range n.children {
 		if !child.reset(c) {
 			return false
@@ -640,9 +768,3 @@ func (n *node) reset(c *copyOnWriteContext) bool {
 	}
 	return c.freeNode(n) != ftFreelistFull
 }
-
-type Int int
-
-func (a Int) Less(b Item) bool {
-	return a < b.(Int)
-}
@@ -1,16 +1,18 @@
 /*
- * @Author: zengzh 
- * @Date: 2023-01-29 10:10:56 
- * @Last Modified by:   zengzh 
- * @Last Modified time: 2023-01-29 10:10:56 
+ * @Author: zengzh
+ * @Date: 2023-01-29 10:10:56
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-24 14:02:37
  */
 package bptree
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"math/rand"
 	"reflect"
+	"sort"
 	"testing"
 	"time"
 )
@@ -112,3 +114,397 @@ func TestBTree(t *testing.T) {
 	}
 	return
 }
+
+// checkTree verifies the B+ tree invariants: all leaves at the same depth,
+// node sizes, separator bounds, and that the leaf links visit every item in
+// order in both directions.
+func checkTree(t *testing.T, tr *BTree) {
+	t.Helper()
+	if tr.root == nil {
+		return
+	}
+	var leaves []*node[Item]
+	depth := -1
+	var walk func(n *node[Item], lo, hi Item, d int)
+	walk = func(n *node[Item], lo, hi Item, d int) {
+		if n != tr.root && len(n.items) < tr.g().minItems() {
+			t.Fatalf("node with %v items, min %v", len(n.items), tr.g().minItems())
+		}
+		if len(n.items) > tr.g().maxItems() {
+			t.Fatalf("node with %v items, max %v", len(n.items), tr.g().maxItems())
+		}
+		for i, item := range n.items {
+			if lo != nil && item.Less(lo) || hi != nil && !item.Less(hi) {
+				t.Fatalf("item %v out of [%v, %v)", item, lo, hi)
+			}
+			if i > 0 && !n.items[i-1].Less(item) {
+				t.Fatalf("items not sorted: %v", n.items)
+			}
+		}
+		if n.leaf() {
+			if depth >= 0 && d != depth {
+				t.Fatalf("leaves at depth %v and %v", depth, d)
+			}
+			depth = d
+			leaves = append(leaves, n)
+			return
+		}
+		if len(n.children) != len(n.items)+1 {
+			t.Fatalf("%v children for %v items", len(n.children), len(n.items))
+		}
+		for i, c := range n.children {
+			clo, chi := lo, hi
+			if i > 0 {
+				clo = n.items[i-1]
+			}
+			if i < len(n.items) {
+				chi = n.items[i]
+			}
+			walk(c, clo, chi, d+1)
+		}
+	}
+	walk(tr.root, nil, nil, 0)
+
+	// only the links between leaves owned by the tree are kept, see
+	// checkLinks
+	owned := func(n *node[Item]) bool {
+		return n != nil && n.cow == tr.cow
+	}
+	count := 0
+	for i, leaf := range leaves {
+		var prev, next *node[Item]
+		if i > 0 {
+			prev = leaves[i-1]
+		}
+		if i < len(leaves)-1 {
+			next = leaves[i+1]
+		}
+		if owned(leaf) && (owned(leaf.prev) && leaf.prev != prev || owned(leaf.next) && leaf.next != next) {
+			t.Fatalf("bad owned links of leaf %v", i)
+		}
+		if tr.g().prevLeaf(leaf) != prev || tr.g().nextLeaf(leaf) != next {
+			t.Fatalf("bad walk from leaf %v", i)
+		}
+		count += len(leaf.items)
+	}
+	if count != tr.Len() {
+		t.Fatalf("len %v, counted %v", tr.Len(), count)
+	}
+}
+
+// checkLinks verifies that all the leaves of a tree never cloned are linked.
+func checkLinks(t *testing.T, tr *BTree) {
+	t.Helper()
+	if tr.root == nil {
+		return
+	}
+	var prev *node[Item]
+	count := 0
+	for n := tr.root.firstLeaf(); n != nil; prev, n = n, n.next {
+		if n.prev != prev {
+			t.Fatalf("bad prev link after %v items", count)
+		}
+		count += len(n.items)
+	}
+	if prev != tr.root.lastLeaf() || count != tr.Len() {
+		t.Fatalf("len %v, linked %v", tr.Len(), count)
+	}
+}
+
+func TestBTreeStructure(t *testing.T) {
+	for _, degree := range []int{2, 3, 8} {
+		tr := New(degree)
+		for _, item := range perm(2000) {
+			tr.ReplaceOrInsert(item)
+			if item.(Int)%97 == 0 {
+				checkTree(t, tr)
+				checkLinks(t, tr)
+			}
+		}
+		checkTree(t, tr)
+		checkLinks(t, tr)
+		for i, item := range perm(2000) {
+			var out Item
+			switch i % 3 {
+			case 0:
+				out = tr.Delete(item)
+			case 1:
+				out = tr.DeleteMin()
+			default:
+				out = tr.DeleteMax()
+			}
+			if out == nil && i%3 != 0 {
+				t.Fatalf("degree %v: nothing deleted at %v", degree, i)
+			}
+			if i%97 == 0 {
+				checkTree(t, tr)
+				checkLinks(t, tr)
+			}
+		}
+		checkTree(t, tr)
+		checkLinks(t, tr)
+		for tr.DeleteMin() != nil {
+		}
+		if tr.Len() != 0 || tr.Min() != nil {
+			t.Fatalf("degree %v: tree should be empty", degree)
+		}
+	}
+}
+
+func TestBTreeRanges(t *testing.T) {
+	tr := New(3)
+	var want []int
+	for _, v := range rand.Perm(300) {
+		tr.ReplaceOrInsert(Int(v * 2))
+	}
+	for i := 0; i < 300; i++ {
+		want = append(want, i*2)
+	}
+	collect := func(scan func(ItemIterator)) (out []int) {
+		scan(func(i Item) bool {
+			out = append(out, int(i.(Int)))
+			return true
+		})
+		return out
+	}
+	filter := func(keep func(v int) bool, reverse bool) (out []int) {
+		for _, v := range want {
+			if keep(v) {
+				out = append(out, v)
+			}
+		}
+		if reverse {
+			sort.Sort(sort.Reverse(sort.IntSlice(out)))
+		}
+		return out
+	}
+	for i := 0; i < 200; i++ {
+		a, b := Int(rand.Intn(620)-10), Int(rand.Intn(620)-10)
+		cases := []struct {
+			name string
+			got  []int
+			want []int
+		}{
+			{"AscendRange", collect(func(f ItemIterator) { tr.AscendRange(a, b, f) }),
+				filter(func(v int) bool { return v >= int(a) && v < int(b) }, false)},
+			{"AscendLessThan", collect(func(f ItemIterator) { tr.AscendLessThan(a, f) }),
+				filter(func(v int) bool { return v < int(a) }, false)},
+			{"AscendGreaterOrEqual", collect(func(f ItemIterator) { tr.AscendGreaterOrEqual(a, f) }),
+				filter(func(v int) bool { return v >= int(a) }, false)},
+			{"DescendRange", collect(func(f ItemIterator) { tr.DescendRange(a, b, f) }),
+				filter(func(v int) bool { return v <= int(a) && v > int(b) }, true)},
+			{"DescendLessOrEqual", collect(func(f ItemIterator) { tr.DescendLessOrEqual(a, f) }),
+				filter(func(v int) bool { return v <= int(a) }, true)},
+			{"DescendGreaterThan", collect(func(f ItemIterator) { tr.DescendGreaterThan(a, f) }),
+				filter(func(v int) bool { return v > int(a) }, true)},
+		}
+		for _, c := range cases {
+			if !reflect.DeepEqual(c.got, c.want) {
+				t.Fatalf("%v(%v, %v): got %v, want %v", c.name, a, b, c.got, c.want)
+			}
+		}
+	}
+
+	var got []int
+	tr.AscendGreaterOrEqual(Int(100), func(i Item) bool {
+		got = append(got, int(i.(Int)))
+		return len(got) < 3
+	})
+	if !reflect.DeepEqual(got, []int{100, 102, 104}) {
+		t.Fatalf("early stop: got %v", got)
+	}
+}
+
+func TestBTreeClone(t *testing.T) {
+	tr := New(2)
+	for _, item := range perm(500) {
+		tr.ReplaceOrInsert(item)
+	}
+	clone := tr.Clone()
+	clone2 := clone.Clone()
+	for i := 0; i < 250; i++ {
+		tr.Delete(Int(i))
+		clone.ReplaceOrInsert(Int(500 + i))
+	}
+	checkTree(t, tr)
+	checkTree(t, clone)
+	checkTree(t, clone2)
+	if !reflect.DeepEqual(all(tr), rang(500)[250:]) {
+		t.Fatalf("bad tree after delete")
+	}
+	if !reflect.DeepEqual(all(clone), rang(750)) {
+		t.Fatalf("bad clone after insert")
+	}
+	if !reflect.DeepEqual(all(clone2), rang(500)) {
+		t.Fatalf("untouched clone changed")
+	}
+	clone2.Clear(true)
+	if tr.Len() != 250 || clone.Len() != 750 || clone2.Len() != 0 {
+		t.Fatalf("clear changed the other trees")
+	}
+}
+
+func TestBTreeCloneRandom(t *testing.T) {
+	// trees cloned from each other and written at random, with the sets of
+	// items they should hold
+	trees := []*BTree{New(2)}
+	sets := []map[int]bool{{}}
+	check := func(i int) {
+		checkTree(t, trees[i])
+		var want []Item
+		for v := range sets[i] {
+			want = append(want, Int(v))
+		}
+		sort.Slice(want, func(a, b int) bool { return want[a].Less(want[b]) })
+		if got := all(trees[i]); len(got) != len(want) || len(got) > 0 && !reflect.DeepEqual(got, want) {
+			t.Fatalf("tree %v: got %v, want %v", i, got, want)
+		}
+		for a, b := 0, len(want)-1; a < b; a, b = a+1, b-1 {
+			want[a], want[b] = want[b], want[a]
+		}
+		if got := allrev(trees[i]); len(got) > 0 && !reflect.DeepEqual(got, want) {
+			t.Fatalf("tree %v descending: got %v, want %v", i, got, want)
+		}
+	}
+	for op := 0; op < 20000; op++ {
+		i := rand.Intn(len(trees))
+		v := rand.Intn(300)
+		switch r := rand.Intn(100); {
+		case r < 2 && len(trees) < 8:
+			trees = append(trees, trees[i].Clone())
+			set := make(map[int]bool, len(sets[i]))
+			for k := range sets[i] {
+				set[k] = true
+			}
+			sets = append(sets, set)
+		case r < 55:
+			trees[i].ReplaceOrInsert(Int(v))
+			sets[i][v] = true
+		case r < 95:
+			trees[i].Delete(Int(v))
+			delete(sets[i], v)
+		case r < 97:
+			if min, ok := trees[i].DeleteMin().(Int); ok {
+				delete(sets[i], int(min))
+			}
+		default:
+			check(i)
+		}
+	}
+	for i := range trees {
+		check(i)
+	}
+}
+
+func TestBTreeClonePathCopy(t *testing.T) {
+	tr := New(4)
+	for _, item := range perm(1 << 14) {
+		tr.ReplaceOrInsert(item)
+	}
+	height := 0
+	for n := tr.root; n != nil; height++ {
+		if n.leaf() {
+			n = nil
+		} else {
+			n = n.children[0]
+		}
+	}
+	clone := tr.Clone()
+	tr.ReplaceOrInsert(Int(-1))
+	tr.Delete(Int(1 << 13))
+
+	// a write copies its path, and the siblings it splits or merges with
+	copied := 0
+	var walk func(n *node[Item])
+	walk = func(n *node[Item]) {
+		if n.cow == tr.cow {
+			copied++
+		}
+		for _, c := range n.children {
+			walk(c)
+		}
+	}
+	walk(tr.root)
+	if copied > 4*2*height {
+		t.Fatalf("%v nodes copied for a tree of height %v", copied, height)
+	}
+	checkTree(t, tr)
+	checkTree(t, clone)
+	if clone.Has(Int(-1)) || !clone.Has(Int(1<<13)) || clone.Len() != 1<<14 {
+		t.Fatalf("the clone should not change")
+	}
+}
+
+func TestBTreeCloneConcurrent(t *testing.T) {
+	tr := New(3)
+	for _, item := range perm(1000) {
+		tr.ReplaceOrInsert(item)
+	}
+	done := make(chan struct{})
+	for g := 0; g < 4; g++ {
+		clone := tr.Clone()
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for i := 0; i < 20; i++ {
+				if got := all(clone); !reflect.DeepEqual(got, rang(1000)) {
+					t.Errorf("clone changed: %v items", len(got))
+					return
+				}
+			}
+		}()
+	}
+	for _, item := range perm(1000) {
+		tr.Delete(item)
+		tr.ReplaceOrInsert(item.(Int) + 1000)
+	}
+	for g := 0; g < 4; g++ {
+		<-done
+	}
+	checkTree(t, tr)
+}
+
+func TestBTreeFreeList(t *testing.T) {
+	f := NewFreeList(64)
+	tr := NewWithFreeList(2, f)
+	for _, item := range perm(100) {
+		tr.ReplaceOrInsert(item)
+	}
+	tr.Clear(true)
+	if len(f.freelist) == 0 {
+		t.Fatalf("clear should have freed nodes")
+	}
+	freed := len(f.freelist)
+	for _, item := range perm(100) {
+		tr.ReplaceOrInsert(item)
+	}
+	if len(f.freelist) >= freed {
+		t.Fatalf("freed nodes should be reused")
+	}
+	checkTree(t, tr)
+}
+
+func TestBTreePrint(t *testing.T) {
+	tr := New(2)
+	for _, item := range rang(4) {
+		tr.ReplaceOrInsert(item)
+	}
+	var buf bytes.Buffer
+	tr.root.print(&buf, 0)
+	if want := "NODE:[1]\n LEAF:[0]\n LEAF:[1 2 3]\n"; buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func BenchmarkCloneInsert(b *testing.B) {
+	tr := New(*btreeDegree)
+	for _, item := range perm(1 << 20) {
+		tr.ReplaceOrInsert(item)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// a reader keeps the snapshot, the writer goes on
+		_ = tr.Clone()
+		tr.ReplaceOrInsert(Int(i % (1 << 20)))
+	}
+}
@@ -0,0 +1,150 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-21 14:22:09
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-24 14:02:37
+ */
+package bptree
+
+import "iter"
+
+// CursorG walks the items of a tree in both directions. Writes to the tree
+// invalidate it, so to iterate while writing, open the cursor on a Clone: the
+// clone keeps its items whatever the original tree does.
+type CursorG[T any] struct {
+	tree *BTreeG[T]
+	leaf *node[T]
+	i    int
+}
+
+// Cursor is the cursor of a BTree.
+type Cursor = CursorG[Item]
+
+// Cursor returns a cursor which is not positioned yet.
+func (t *BTreeG[T]) Cursor() *CursorG[T] {
+	return &CursorG[T]{tree: t}
+}
+
+func (t *BTree) Cursor() *Cursor {
+	return t.g().Cursor()
+}
+
+// Valid reports whether the cursor is on an item.
+func (c *CursorG[T]) Valid() bool {
+	return c.leaf != nil
+}
+
+// Item returns the item under the cursor, or the zero value if it is not
+// valid.
+func (c *CursorG[T]) Item() (_ T) {
+	if c.leaf == nil {
+		return
+	}
+	return c.leaf.items[c.i]
+}
+
+func (c *CursorG[T]) invalidate() bool {
+	c.leaf, c.i = nil, 0
+	return false
+}
+
+// First moves to the smallest item.
+func (c *CursorG[T]) First() bool {
+	if c.tree.length == 0 {
+		return c.invalidate()
+	}
+	c.leaf, c.i = c.tree.root.firstLeaf(), 0
+	return true
+}
+
+// Last moves to the largest item.
+func (c *CursorG[T]) Last() bool {
+	if c.tree.length == 0 {
+		return c.invalidate()
+	}
+	c.leaf = c.tree.root.lastLeaf()
+	c.i = len(c.leaf.items) - 1
+	return true
+}
+
+// Seek moves to the smallest item greater or equal to key.
+func (c *CursorG[T]) Seek(key T) bool {
+	if c.tree.length == 0 {
+		return c.invalidate()
+	}
+	c.leaf = c.tree.root.findLeaf(key)
+	c.i, _ = c.leaf.items.find(key, c.tree.cow.less)
+	if c.i == len(c.leaf.items) {
+		c.i--
+		return c.Next()
+	}
+	return true
+}
+
+// Next moves to the next item, the cursor becomes invalid past the last one.
+func (c *CursorG[T]) Next() bool {
+	if c.leaf == nil {
+		return false
+	}
+	if c.i++; c.i < len(c.leaf.items) {
+		return true
+	}
+	// leaves other than an emptied root are never empty
+	if c.leaf = c.tree.nextLeaf(c.leaf); c.leaf == nil {
+		return c.invalidate()
+	}
+	c.i = 0
+	return true
+}
+
+// Prev moves to the previous item, the cursor becomes invalid before the
+// first one.
+func (c *CursorG[T]) Prev() bool {
+	if c.leaf == nil {
+		return false
+	}
+	if c.i--; c.i >= 0 {
+		return true
+	}
+	if c.leaf = c.tree.prevLeaf(c.leaf); c.leaf == nil {
+		return c.invalidate()
+	}
+	c.i = len(c.leaf.items) - 1
+	return true
+}
+
+// All returns the items in ascending order.
+func (t *BTreeG[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		t.Ascend(yield)
+	}
+}
+
+// Backward returns the items in descending order.
+func (t *BTreeG[T]) Backward() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		t.Descend(yield)
+	}
+}
+
+// Range returns the items in [greaterOrEqual, lessThan) in ascending order.
+func (t *BTreeG[T]) Range(greaterOrEqual, lessThan T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		t.AscendRange(greaterOrEqual, lessThan, yield)
+	}
+}
+
+func (t *BTree) All() iter.Seq[Item] {
+	return t.g().All()
+}
+
+func (t *BTree) Backward() iter.Seq[Item] {
+	return t.g().Backward()
+}
+
+// Range is like AscendRange, a nil bound leaves its end of the range open.
+func (t *BTree) Range(greaterOrEqual, lessThan Item) iter.Seq[Item] {
+	return func(yield func(Item) bool) {
+		t.AscendRange(greaterOrEqual, lessThan, ItemIterator(yield))
+	}
+}
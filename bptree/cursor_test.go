@@ -0,0 +1,139 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-21 15:02:17
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-21 15:36:41
+ */
+package bptree
+
+import (
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestCursor(t *testing.T) {
+	tr := NewOrderedG[int](2)
+	c := tr.Cursor()
+	if c.First() || c.Last() || c.Seek(1) || c.Valid() || c.Next() || c.Prev() {
+		t.Fatalf("cursor on an empty tree should be invalid")
+	}
+	var want []int
+	for _, v := range rand.Perm(500) {
+		tr.ReplaceOrInsert(v * 2)
+	}
+	for i := 0; i < 500; i++ {
+		want = append(want, i*2)
+	}
+
+	var got []int
+	for ok := c.First(); ok; ok = c.Next() {
+		got = append(got, c.Item())
+	}
+	if !reflect.DeepEqual(got, want) || c.Valid() {
+		t.Fatalf("forward: got %v", got)
+	}
+	got = got[:0]
+	for ok := c.Last(); ok; ok = c.Prev() {
+		got = append(got, c.Item())
+	}
+	if slices.Reverse(got); !reflect.DeepEqual(got, want) {
+		t.Fatalf("backward: got %v", got)
+	}
+
+	for _, key := range []int{-5, 0, 1, 2, 501, 997, 998, 999, 2000} {
+		ok := c.Seek(key)
+		i, _ := slices.BinarySearch(want, key)
+		if ok != (i < len(want)) {
+			t.Fatalf("seek %v: got %v", key, ok)
+		}
+		if ok && c.Item() != want[i] {
+			t.Fatalf("seek %v: got %v, want %v", key, c.Item(), want[i])
+		}
+	}
+
+	// change direction in the middle
+	c.Seek(100)
+	for i := 0; i < 7; i++ {
+		c.Next()
+	}
+	for i := 0; i < 10; i++ {
+		c.Prev()
+	}
+	if c.Item() != 94 {
+		t.Fatalf("got %v, want 94", c.Item())
+	}
+}
+
+func TestCursorClone(t *testing.T) {
+	tr := New(2)
+	for _, item := range perm(300) {
+		tr.ReplaceOrInsert(item)
+	}
+	snapshot := tr.Clone()
+	c := snapshot.Cursor()
+	var got []Item
+	for ok := c.First(); ok; ok = c.Next() {
+		got = append(got, c.Item())
+		// rewrite the original as the snapshot is walked
+		tr.Delete(c.Item())
+		tr.ReplaceOrInsert(Int(1000 + len(got)))
+		if len(got)%50 == 0 {
+			tr.Clear(true)
+		}
+	}
+	if !reflect.DeepEqual(got, rang(300)) {
+		t.Fatalf("snapshot changed: got %v items", len(got))
+	}
+	checkTree(t, tr)
+	checkTree(t, snapshot)
+	if c.Item() != nil {
+		t.Fatalf("invalid cursor should have a nil item")
+	}
+}
+
+func TestIterators(t *testing.T) {
+	tr := NewOrderedG[int](3)
+	for _, v := range rand.Perm(100) {
+		tr.ReplaceOrInsert(v)
+	}
+	if got := slices.Collect(tr.All()); !reflect.DeepEqual(got, rangeInts(0, 100)) {
+		t.Fatalf("all: got %v", got)
+	}
+	back := slices.Collect(tr.Backward())
+	if slices.Reverse(back); !reflect.DeepEqual(back, rangeInts(0, 100)) {
+		t.Fatalf("backward: got %v", back)
+	}
+	if got := slices.Collect(tr.Range(10, 20)); !reflect.DeepEqual(got, rangeInts(10, 20)) {
+		t.Fatalf("range: got %v", got)
+	}
+	var got []int
+	for v := range tr.All() {
+		if v == 3 {
+			break
+		}
+		got = append(got, v)
+	}
+	if !reflect.DeepEqual(got, []int{0, 1, 2}) {
+		t.Fatalf("break: got %v", got)
+	}
+
+	items := New(2)
+	for _, item := range perm(10) {
+		items.ReplaceOrInsert(item)
+	}
+	if got := slices.Collect(items.Range(nil, Int(3))); !reflect.DeepEqual(got, rang(3)) {
+		t.Fatalf("item range: got %v", got)
+	}
+	if got := slices.Collect(items.Backward()); !reflect.DeepEqual(got, rangrev(10)) {
+		t.Fatalf("item backward: got %v", got)
+	}
+}
+
+func rangeInts(from, to int) (out []int) {
+	for i := from; i < to; i++ {
+		out = append(out, i)
+	}
+	return
+}
@@ -0,0 +1,604 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-22 10:31:47
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-25 10:14:52
+ */
+package bptree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+)
+
+type Options struct {
+	// PageSize is the size of the pages, fixed when the file is created.
+	PageSize int
+	// CacheSize is the number of pages kept in memory.
+	CacheSize int
+	// CheckpointFrames is the number of pages logged in the write ahead log
+	// before a checkpoint.
+	CheckpointFrames int
+}
+
+// DiskTree is a B+ tree of byte keys and values stored in the pages of a
+// file. Like BTreeG, it splits the full nodes on the way down to a leaf to
+// insert into, and grows the nodes taking less than a quarter of their page
+// by stealing an item from a sibling or merging with it. Both trees share
+// these steps through nodeBody, but the disk tree measures nodes in bytes
+// instead of items, since its keys and values vary in size. For the same
+// reason it grows the nodes on the way back up from the leaf it deleted
+// from: a stolen item gives the parent a separator which may be longer than
+// the one it replaces, and a parent which overflows is split then. The leaf
+// links are page ids to load and write, so the disk tree updates them
+// itself.
+//
+// Every write is committed to a write ahead log next to the file, and is
+// durable after Sync. The log is copied into the file by checkpoints, and
+// replayed by Open after a crash. It is safe for concurrent use.
+type DiskTree struct {
+	mu               sync.Mutex
+	pager            *pager
+	maxEntry         int
+	checkpointFrames int
+	closed           bool
+}
+
+func bytesLess(a, b []byte) bool {
+	return bytes.Compare(a, b) < 0
+}
+
+func Open(path string) (*DiskTree, error) {
+	return OpenWithOptions(path, Options{})
+}
+
+// OpenWithOptions opens the tree in the file at path, creating it if needed.
+// The page size of an existing file is read from it.
+func OpenWithOptions(path string, opts Options) (*DiskTree, error) {
+	if opts.PageSize == 0 {
+		opts.PageSize = DefaultPageSize
+	}
+	if opts.CacheSize == 0 {
+		opts.CacheSize = DefaultCacheSize
+	}
+	if opts.CheckpointFrames == 0 {
+		opts.CheckpointFrames = DefaultCheckpointFrames
+	}
+	if opts.PageSize < MinPageSize || opts.PageSize > 1<<20 || opts.CacheSize < 0 || opts.CheckpointFrames < 0 {
+		return nil, errors.New("invalid options")
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	t, err := openFile(file, path+"-wal", opts)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+func openFile(file *os.File, walPath string, opts Options) (*DiskTree, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	pageSize := opts.PageSize
+	if info.Size() == 0 {
+		// a log left by another file of the same name
+		if err = os.Remove(walPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	} else if pageSize, err = readPageSize(file, walPath); err != nil {
+		return nil, err
+	}
+
+	p := newPager(file, pageSize, opts.CacheSize)
+	if p.wal, err = openWAL(walPath, pageSize); err != nil {
+		return nil, err
+	}
+	if info.Size() == 0 {
+		if err = createFile(p); err != nil {
+			p.wal.file.Close()
+			return nil, err
+		}
+	}
+	// recover, by copying the operations committed to the log into the file
+	if err = p.checkpoint(); err != nil {
+		p.wal.file.Close()
+		return nil, err
+	}
+	page := make([]byte, metaSize)
+	if _, err = file.ReadAt(page, 0); err == nil {
+		err = p.meta.decode(page)
+	}
+	if err != nil || p.meta.pageSize != uint32(pageSize) || p.meta.numPages < 2 || uint64(p.meta.root) >= p.meta.numPages {
+		p.wal.file.Close()
+		return nil, ErrBadFile
+	}
+	p.committed = p.meta
+	return &DiskTree{
+		pager:            p,
+		maxEntry:         pageSize / 4,
+		checkpointFrames: opts.CheckpointFrames,
+	}, nil
+}
+
+// createFile commits an empty tree to the log of a new file. The file is
+// only written by the checkpoint, so a crash leaves it empty, and it is
+// created again, or with the log to recover it from.
+func createFile(p *pager) error {
+	p.meta = meta{pageSize: uint32(p.pageSize), root: 1, numPages: 2}
+	root := make([]byte, p.pageSize)
+	(&dnode{id: 1}).encode(root)
+	page := make([]byte, p.pageSize)
+	p.meta.encode(page)
+	return p.wal.append([]walPage{{1, root}, {0, page}})
+}
+
+// readPageSize reads the page size from the meta page, or from the log if a
+// checkpoint was interrupted while writing the meta page.
+func readPageSize(file *os.File, walPath string) (int, error) {
+	var m meta
+	size := 0
+	page := make([]byte, metaSize)
+	if _, err := file.ReadAt(page, 0); err == nil && m.decode(page) == nil {
+		size = int(m.pageSize)
+	} else if walSize, ok := walPageSize(walPath); ok {
+		size = walSize
+	}
+	if size < MinPageSize || size > 1<<20 {
+		return 0, ErrBadFile
+	}
+	return size, nil
+}
+
+// full reports whether n may not have room for one more entry.
+func (t *DiskTree) full(n *dnode) bool {
+	return n.size()+t.maxEntry > t.pager.pageSize
+}
+
+func (t *DiskTree) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return int(t.pager.meta.length)
+}
+
+// findLeaf returns the leaf that may hold key.
+func (t *DiskTree) findLeaf(key []byte) (*dnode, error) {
+	n, err := t.pager.node(t.pager.meta.root)
+	for err == nil && !n.leaf() {
+		n, err = t.pager.node(n.children[n.items.childIndex(key, bytesLess)])
+	}
+	return n, err
+}
+
+// Get returns a copy of the value of key.
+func (t *DiskTree) Get(key []byte) (value []byte, ok bool, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil, false, ErrClosed
+	}
+	defer t.pager.trim()
+
+	n, err := t.findLeaf(key)
+	if err != nil {
+		return nil, false, err
+	}
+	if i, found := n.items.find(key, bytesLess); found {
+		return bytes.Clone(n.values[i]), true, nil
+	}
+	return nil, false, nil
+}
+
+// finish commits a write, or rolls it back if it failed.
+func (t *DiskTree) finish(err *error) {
+	p := t.pager
+	if *err == nil {
+		*err = p.commit()
+	}
+	if *err != nil {
+		p.rollback()
+		return
+	}
+	if p.wal.frames >= t.checkpointFrames {
+		*err = p.checkpoint()
+	}
+	p.trim()
+}
+
+// Put sets the value of key, returns ErrTooLarge if they take more than a
+// quarter of a page.
+func (t *DiskTree) Put(key, value []byte) (err error) {
+	if leafEntrySize(key, value)+8 > t.maxEntry {
+		return ErrTooLarge
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return ErrClosed
+	}
+	defer t.finish(&err)
+
+	p := t.pager
+	n, err := p.node(p.meta.root)
+	if err != nil {
+		return err
+	}
+	if t.full(n) {
+		if n, err = t.splitRoot(n); err != nil {
+			return err
+		}
+	}
+	for !n.leaf() {
+		i := n.items.childIndex(key, bytesLess)
+		child, err := p.node(n.children[i])
+		if err != nil {
+			return err
+		}
+		if t.full(child) {
+			if err = t.splitChild(n, i, child); err != nil {
+				return err
+			}
+			if !bytesLess(key, n.items[i]) {
+				if child, err = p.node(n.children[i+1]); err != nil {
+					return err
+				}
+			}
+		}
+		n = child
+	}
+
+	i, found := n.items.find(key, bytesLess)
+	if found {
+		n.values[i] = bytes.Clone(value)
+	} else {
+		n.items.insertAt(i, bytes.Clone(key))
+		n.values.insertAt(i, bytes.Clone(value))
+		p.meta.length++
+	}
+	p.markDirty(n)
+	return nil
+}
+
+// splitIndex returns where to split n so both halves take about the same
+// space, the middle item would leave a half too large for a page when the
+// items vary in size.
+func splitIndex(n *dnode) int {
+	total := n.size()
+	size := 0
+	for i := range n.items {
+		if size += n.entrySize(i); size*2 >= total {
+			return max(1, min(i, len(n.items)-2))
+		}
+	}
+	return len(n.items) / 2
+}
+
+// splitRoot splits the root n under a new root, and returns it.
+func (t *DiskTree) splitRoot(n *dnode) (*dnode, error) {
+	p := t.pager
+	root, err := p.alloc()
+	if err != nil {
+		return nil, err
+	}
+	root.children = append(root.children, n.id)
+	if err = t.splitChild(root, 0, n); err != nil {
+		return nil, err
+	}
+	p.meta.root = root.id
+	return root, nil
+}
+
+// splitChild moves the upper half of child i of parent into a new node.
+func (t *DiskTree) splitChild(parent *dnode, i int, child *dnode) error {
+	p := t.pager
+	next, err := p.alloc()
+	if err != nil {
+		return err
+	}
+	sep := child.splitInto(splitIndex(child), &next.nodeBody)
+	if child.leaf() {
+		next.prev, next.next = child.id, child.next
+		if child.next != 0 {
+			after, err := p.node(child.next)
+			if err != nil {
+				return err
+			}
+			after.prev = next.id
+			p.markDirty(after)
+		}
+		child.next = next.id
+	}
+	parent.items.insertAt(i, sep)
+	parent.children.insertAt(i+1, next.id)
+	p.markDirty(child)
+	p.markDirty(next)
+	p.markDirty(parent)
+	return nil
+}
+
+// underfull reports whether n takes less than a quarter of a page.
+func (t *DiskTree) underfull(n *dnode) bool {
+	return n.size() < t.pager.pageSize/4
+}
+
+// Delete removes key, and reports whether it was present.
+func (t *DiskTree) Delete(key []byte) (found bool, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return false, ErrClosed
+	}
+	defer t.finish(&err)
+
+	// path has the nodes down to the leaf, index the child taken in each
+	p := t.pager
+	var path []*dnode
+	var index []int
+	n, err := p.node(p.meta.root)
+	for err == nil && !n.leaf() {
+		i := n.items.childIndex(key, bytesLess)
+		path, index = append(path, n), append(index, i)
+		n, err = p.node(n.children[i])
+	}
+	if err != nil {
+		return false, err
+	}
+	i, found := n.items.find(key, bytesLess)
+	if !found {
+		return false, nil
+	}
+	n.items.removeAt(i)
+	n.values.removeAt(i)
+	p.markDirty(n)
+	p.meta.length--
+
+	// a node is fixed by its parent, which may overflow or become underfull
+	// then, and is fixed in turn
+	for l := len(path) - 1; l >= 0; l-- {
+		switch parent, i := path[l], index[l]; {
+		case n.size() > p.pageSize:
+			err = t.splitChild(parent, i, n)
+		case t.underfull(n):
+			err = t.growChild(parent, i, n)
+		}
+		if err != nil {
+			return true, err
+		}
+		n = path[l]
+	}
+	if n.size() > p.pageSize {
+		_, err = t.splitRoot(n)
+	}
+	for err == nil && !n.leaf() && len(n.items) == 0 {
+		p.meta.root = n.children[0]
+		p.free(n)
+		n, err = p.node(p.meta.root)
+	}
+	return true, err
+}
+
+// growChild grows child i of parent by stealing an item from a sibling or
+// merging with it, like BTreeG does. A sibling is lent an item as long as it
+// keeps a quarter of a page, so the child is merged only with a sibling
+// small enough to fit in its page. The separator of a steal may make parent
+// overflow, Delete splits it then.
+func (t *DiskTree) growChild(parent *dnode, i int, child *dnode) error {
+	p := t.pager
+	var left, right *dnode
+	var err error
+	if i > 0 {
+		if left, err = p.node(parent.children[i-1]); err != nil {
+			return err
+		}
+		if t.lends(left, len(left.items)-1) {
+			parent.stealLeft(i, &child.nodeBody, &left.nodeBody)
+			p.markDirty(left)
+			p.markDirty(child)
+			p.markDirty(parent)
+			return nil
+		}
+	}
+	if i+1 < len(parent.children) {
+		if right, err = p.node(parent.children[i+1]); err != nil {
+			return err
+		}
+		if t.lends(right, 0) {
+			parent.stealRight(i, &child.nodeBody, &right.nodeBody)
+			p.markDirty(right)
+			p.markDirty(child)
+			p.markDirty(parent)
+			return nil
+		}
+	}
+	if right == nil {
+		if left == nil {
+			// only the root may have a single child
+			return fmt.Errorf("page %d: %w", parent.id, ErrBadPage)
+		}
+		i, child, right = i-1, left, child
+	}
+	return t.merge(parent, i, child, right)
+}
+
+// lends reports whether sibling can lend its item j and keep a quarter of a
+// page.
+func (t *DiskTree) lends(sibling *dnode, j int) bool {
+	return len(sibling.items) > 1 && sibling.size()-sibling.entrySize(j) >= t.pager.pageSize/4
+}
+
+// merge moves right, child i+1 of parent, into left.
+func (t *DiskTree) merge(parent *dnode, i int, left, right *dnode) error {
+	p := t.pager
+	size := left.size() + right.size() - leafHeaderSize
+	if !left.leaf() {
+		size = left.size() + right.size() - internalHeaderSize + internalEntrySize(parent.items[i])
+	}
+	if size > p.pageSize {
+		// a sibling too large to merge with can lend an item
+		return fmt.Errorf("page %d: %w", left.id, ErrBadPage)
+	}
+	if left.leaf() {
+		left.next = right.next
+		if right.next != 0 {
+			after, err := p.node(right.next)
+			if err != nil {
+				return err
+			}
+			after.prev = left.id
+			p.markDirty(after)
+		}
+	}
+	parent.merge(i, &left.nodeBody, &right.nodeBody)
+	p.markDirty(left)
+	p.markDirty(parent)
+	p.free(right)
+	return nil
+}
+
+// AscendRange calls fn for the keys in [greaterOrEqual, lessThan), a nil
+// bound leaves its end of the range open. The slices passed to fn must not
+// be modified or kept.
+func (t *DiskTree) AscendRange(greaterOrEqual, lessThan []byte, fn func(key, value []byte) bool) (err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return ErrClosed
+	}
+	defer t.pager.trim()
+
+	p := t.pager
+	var n *dnode
+	if greaterOrEqual == nil {
+		n, err = p.node(p.meta.root)
+		for err == nil && !n.leaf() {
+			n, err = p.node(n.children[0])
+		}
+	} else {
+		n, err = t.findLeaf(greaterOrEqual)
+	}
+	if err != nil {
+		return err
+	}
+	i := 0
+	if greaterOrEqual != nil {
+		i = sort.Search(len(n.items), func(i int) bool {
+			return !bytesLess(n.items[i], greaterOrEqual)
+		})
+	}
+	for {
+		for ; i < len(n.items); i++ {
+			if lessThan != nil && !bytesLess(n.items[i], lessThan) {
+				return nil
+			}
+			if !fn(n.items[i], n.values[i]) {
+				return nil
+			}
+		}
+		if n.next == 0 {
+			return nil
+		}
+		if n, err = p.node(n.next); err != nil {
+			return err
+		}
+		i = 0
+		// scanning must not fill the cache with the whole tree
+		p.trim()
+	}
+}
+
+// DescendRange calls fn for the keys in (greaterThan, lessOrEqual] in
+// descending order, a nil bound leaves its end of the range open.
+func (t *DiskTree) DescendRange(lessOrEqual, greaterThan []byte, fn func(key, value []byte) bool) (err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return ErrClosed
+	}
+	defer t.pager.trim()
+
+	p := t.pager
+	var n *dnode
+	if lessOrEqual == nil {
+		n, err = p.node(p.meta.root)
+		for err == nil && !n.leaf() {
+			n, err = p.node(n.children[len(n.children)-1])
+		}
+	} else {
+		n, err = t.findLeaf(lessOrEqual)
+	}
+	if err != nil {
+		return err
+	}
+	i := len(n.items) - 1
+	if lessOrEqual != nil {
+		i = n.items.childIndex(lessOrEqual, bytesLess) - 1
+	}
+	for {
+		for ; i >= 0; i-- {
+			if greaterThan != nil && !bytesLess(greaterThan, n.items[i]) {
+				return nil
+			}
+			if !fn(n.items[i], n.values[i]) {
+				return nil
+			}
+		}
+		if n.prev == 0 {
+			return nil
+		}
+		if n, err = p.node(n.prev); err != nil {
+			return err
+		}
+		i = len(n.items) - 1
+		p.trim()
+	}
+}
+
+// Sync flushes the write ahead log to disk, making the writes durable.
+func (t *DiskTree) Sync() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return ErrClosed
+	}
+	return t.pager.wal.file.Sync()
+}
+
+// Checkpoint copies the write ahead log into the file and empties it.
+func (t *DiskTree) Checkpoint() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return ErrClosed
+	}
+	return t.pager.checkpoint()
+}
+
+// Close checkpoints and closes the file.
+func (t *DiskTree) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return ErrClosed
+	}
+	t.closed = true
+	err := t.pager.checkpoint()
+	if e := t.pager.wal.file.Close(); err == nil {
+		err = e
+	}
+	if e := t.pager.file.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+	return nil
+}
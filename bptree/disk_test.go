@@ -0,0 +1,281 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-22 13:12:05
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-25 10:14:52
+ */
+package bptree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// checkDisk verifies the pages of the tree: sizes, no empty page but the
+// root, separator bounds, leaves at the same depth and linked in order, and
+// the number of keys.
+func checkDisk(t *testing.T, tr *DiskTree) {
+	t.Helper()
+	p := tr.pager
+	var leaves []*dnode
+	depth := -1
+	var walk func(id pageID, lo, hi []byte, d int)
+	walk = func(id pageID, lo, hi []byte, d int) {
+		n, err := p.node(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n.size() > p.pageSize {
+			t.Fatalf("page %v takes %v bytes", id, n.size())
+		}
+		if id != p.meta.root && len(n.items) == 0 {
+			t.Fatalf("page %v is empty", id)
+		}
+		for i, key := range n.items {
+			if lo != nil && bytesLess(key, lo) || hi != nil && !bytesLess(key, hi) {
+				t.Fatalf("key %q out of [%q, %q)", key, lo, hi)
+			}
+			if i > 0 && !bytesLess(n.items[i-1], key) {
+				t.Fatalf("keys of page %v not sorted", id)
+			}
+		}
+		if n.leaf() {
+			if depth >= 0 && d != depth {
+				t.Fatalf("leaves at depth %v and %v", depth, d)
+			}
+			depth = d
+			leaves = append(leaves, n)
+			return
+		}
+		for i, c := range n.children {
+			clo, chi := lo, hi
+			if i > 0 {
+				clo = n.items[i-1]
+			}
+			if i < len(n.items) {
+				chi = n.items[i]
+			}
+			walk(c, clo, chi, d+1)
+		}
+	}
+	walk(p.meta.root, nil, nil, 0)
+
+	count := 0
+	for i, leaf := range leaves {
+		var prev, next pageID
+		if i > 0 {
+			prev = leaves[i-1].id
+		}
+		if i < len(leaves)-1 {
+			next = leaves[i+1].id
+		}
+		if leaf.prev != prev || leaf.next != next {
+			t.Fatalf("bad links of leaf %v", leaf.id)
+		}
+		count += len(leaf.items)
+	}
+	if uint64(count) != p.meta.length {
+		t.Fatalf("length %v, counted %v", p.meta.length, count)
+	}
+	p.trim()
+}
+
+func openTemp(t *testing.T, opts Options) (*DiskTree, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tree.db")
+	tr, err := OpenWithOptions(path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tr, path
+}
+
+func diskKey(i int) []byte {
+	return []byte(fmt.Sprintf("key-%05d", i))
+}
+
+func diskKeys(tr *DiskTree, ascend bool, from, to []byte) (keys []string) {
+	fn := func(k, v []byte) bool {
+		keys = append(keys, string(k))
+		return true
+	}
+	if ascend {
+		tr.AscendRange(from, to, fn)
+	} else {
+		tr.DescendRange(from, to, fn)
+	}
+	return keys
+}
+
+func TestDiskTree(t *testing.T) {
+	opts := Options{PageSize: MinPageSize, CacheSize: 4}
+	tr, path := openTemp(t, opts)
+	want := map[string]string{}
+	for i := 0; i < 6000; i++ {
+		k := diskKey(rand.Intn(2000))
+		if rand.Intn(3) == 0 {
+			found, err := tr.Delete(k)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := want[string(k)]; ok != found {
+				t.Fatalf("delete %s: got %v", k, found)
+			}
+			delete(want, string(k))
+		} else {
+			v := bytes.Repeat(k[len(k)-1:], rand.Intn(60))
+			if err := tr.Put(k, v); err != nil {
+				t.Fatal(err)
+			}
+			want[string(k)] = string(v)
+		}
+		if i%1000 == 999 {
+			checkDisk(t, tr)
+			if err := tr.Close(); err != nil {
+				t.Fatal(err)
+			}
+			var err error
+			if tr, err = OpenWithOptions(path, opts); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	defer tr.Close()
+	checkDisk(t, tr)
+
+	if tr.Len() != len(want) {
+		t.Fatalf("len: got %v, want %v", tr.Len(), len(want))
+	}
+	for k, v := range want {
+		got, ok, err := tr.Get([]byte(k))
+		if err != nil || !ok || string(got) != v {
+			t.Fatalf("get %s: got %q, %v, %v", k, got, ok, err)
+		}
+	}
+	if _, ok, _ := tr.Get([]byte("nope")); ok {
+		t.Fatalf("nope should not be found")
+	}
+
+	var sorted []string
+	for k := range want {
+		sorted = append(sorted, k)
+	}
+	slices.Sort(sorted)
+	if got := diskKeys(tr, true, nil, nil); !slices.Equal(got, sorted) {
+		t.Fatalf("ascend: got %v keys, want %v", len(got), len(sorted))
+	}
+	from, to := diskKey(500), diskKey(700)
+	var inRange []string
+	for _, k := range sorted {
+		if k >= string(from) && k < string(to) {
+			inRange = append(inRange, k)
+		}
+	}
+	if got := diskKeys(tr, true, from, to); !slices.Equal(got, inRange) {
+		t.Fatalf("ascend range: got %v", got)
+	}
+	var desc []string
+	for _, k := range slices.Backward(sorted) {
+		if k <= string(to) && k > string(from) {
+			desc = append(desc, k)
+		}
+	}
+	if got := diskKeys(tr, false, to, from); !slices.Equal(got, desc) {
+		t.Fatalf("descend range: got %v", got)
+	}
+}
+
+func TestDiskTreeKeySizes(t *testing.T) {
+	// with keys of very different sizes, the separator of a steal may not fit
+	// in place of the one it replaces
+	for _, seed := range []int64{1, 2, 3, 1297} {
+		r := rand.New(rand.NewSource(seed))
+		tr, _ := openTemp(t, Options{PageSize: 512, CacheSize: 8})
+		want := map[string]string{}
+		var keys []string
+		for i := 0; i < 10000; i++ {
+			if len(keys) > 0 && r.Intn(2) == 0 {
+				k := keys[r.Intn(len(keys))]
+				found, err := tr.Delete([]byte(k))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, ok := want[k]; ok != found {
+					t.Fatalf("seed %v: delete %q: got %v", seed, k, found)
+				}
+				delete(want, k)
+			} else {
+				k := make([]byte, 1+r.Intn(100))
+				r.Read(k)
+				v := bytes.Repeat([]byte{'v'}, r.Intn(16))
+				if err := tr.Put(k, v); err != nil {
+					t.Fatal(err)
+				}
+				want[string(k)] = string(v)
+				keys = append(keys, string(k))
+			}
+			if i%2000 == 1999 {
+				checkDisk(t, tr)
+			}
+		}
+		verifyDisk(t, tr, want)
+		if err := tr.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDiskTreeFreePages(t *testing.T) {
+	tr, _ := openTemp(t, Options{PageSize: MinPageSize, CacheSize: 8})
+	defer tr.Close()
+	value := make([]byte, 50)
+	for i := 0; i < 1000; i++ {
+		tr.Put(diskKey(i), value)
+	}
+	pages := tr.pager.meta.numPages
+	for i := 0; i < 1000; i++ {
+		if found, err := tr.Delete(diskKey(i)); !found || err != nil {
+			t.Fatalf("delete %v: %v, %v", i, found, err)
+		}
+	}
+	checkDisk(t, tr)
+	if tr.pager.meta.freeHead == 0 {
+		t.Fatalf("deleting should have freed pages")
+	}
+	for i := 0; i < 1000; i++ {
+		tr.Put(diskKey(i), value)
+	}
+	checkDisk(t, tr)
+	if err := tr.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if tr.pager.meta.numPages != pages {
+		t.Fatalf("free pages not reused: %v pages, was %v", tr.pager.meta.numPages, pages)
+	}
+}
+
+func TestDiskTreeErrors(t *testing.T) {
+	tr, path := openTemp(t, Options{})
+	if err := tr.Put([]byte("k"), make([]byte, DefaultPageSize/4)); err != ErrTooLarge {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+	if err := tr.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Put([]byte("k"), nil); err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("something else"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); !errors.Is(err, ErrBadFile) {
+		t.Fatalf("expected ErrBadFile, got %v", err)
+	}
+}
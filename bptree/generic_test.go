@@ -0,0 +1,114 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-21 10:48:25
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-21 11:07:52
+ */
+package bptree
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func allG[T any](t *BTreeG[T]) (out []T) {
+	t.Ascend(func(item T) bool {
+		out = append(out, item)
+		return true
+	})
+	return
+}
+
+func TestBTreeG(t *testing.T) {
+	tr := NewOrderedG[int](*btreeDegree)
+	const treeSize = 1000
+	if _, ok := tr.Min(); ok {
+		t.Fatalf("empty tree should have no min")
+	}
+	for _, v := range rand.Perm(treeSize) {
+		if _, ok := tr.ReplaceOrInsert(v); ok {
+			t.Fatalf("insert found %v", v)
+		}
+	}
+	// zero is an item like any other
+	if v, ok := tr.Get(0); !ok || v != 0 {
+		t.Fatalf("0 should be found")
+	}
+	if v, ok := tr.ReplaceOrInsert(0); !ok || v != 0 {
+		t.Fatalf("0 should be replaced")
+	}
+	if v, ok := tr.Min(); !ok || v != 0 {
+		t.Fatalf("min: got %v", v)
+	}
+	if v, ok := tr.Max(); !ok || v != treeSize-1 {
+		t.Fatalf("max: got %v", v)
+	}
+	var got []int
+	tr.DescendRange(10, 5, func(v int) bool {
+		got = append(got, v)
+		return true
+	})
+	if !reflect.DeepEqual(got, []int{10, 9, 8, 7, 6}) {
+		t.Fatalf("descend range: got %v", got)
+	}
+	for _, v := range rand.Perm(treeSize) {
+		if _, ok := tr.Delete(v); !ok {
+			t.Fatalf("delete didn't find %v", v)
+		}
+	}
+	if _, ok := tr.Delete(1); ok || tr.Len() != 0 {
+		t.Fatalf("tree should be empty")
+	}
+	if _, ok := tr.DeleteMin(); ok {
+		t.Fatalf("empty tree should have no min")
+	}
+}
+
+func TestBTreeGLess(t *testing.T) {
+	type kv struct {
+		key   string
+		value int
+	}
+	// by key, in reverse
+	tr := NewG(2, func(a, b kv) bool { return a.key > b.key })
+	for i, k := range []string{"b", "d", "a", "c"} {
+		tr.ReplaceOrInsert(kv{k, i})
+	}
+	if old, ok := tr.ReplaceOrInsert(kv{"a", 9}); !ok || old.value != 2 {
+		t.Fatalf("replaced: got %v", old)
+	}
+	if v, ok := tr.Get(kv{key: "a"}); !ok || v.value != 9 {
+		t.Fatalf("get a: got %v", v)
+	}
+	var keys []string
+	for _, item := range allG(tr) {
+		keys = append(keys, item.key)
+	}
+	if !reflect.DeepEqual(keys, []string{"d", "c", "b", "a"}) {
+		t.Fatalf("got %v", keys)
+	}
+	clone := tr.Clone()
+	tr.DeleteMax()
+	if clone.Len() != 4 || tr.Has(kv{key: "a"}) || !clone.Has(kv{key: "a"}) {
+		t.Fatalf("clone should keep a")
+	}
+}
+
+func BenchmarkInsert(b *testing.B) {
+	values := rand.Perm(1 << 16)
+	b.Run("Item", func(b *testing.B) {
+		tr := New(*btreeDegree)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			tr.ReplaceOrInsert(Int(values[i%len(values)]))
+		}
+	})
+	b.Run("Generic", func(b *testing.B) {
+		tr := NewOrderedG[int](*btreeDegree)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			tr.ReplaceOrInsert(values[i%len(values)])
+		}
+	})
+}
@@ -0,0 +1,141 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-21 10:14:36
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-24 14:02:37
+ */
+package bptree
+
+type Item interface {
+	Less(than Item) bool
+}
+
+type ItemIterator func(i Item) bool
+
+type FreeList FreeListG[Item]
+
+func NewFreeList(size int) *FreeList {
+	return (*FreeList)(NewFreeListG[Item](size))
+}
+
+func itemLess(a, b Item) bool {
+	return a.Less(b)
+}
+
+// BTree is the tree of Items, missing items are returned as nil.
+type BTree BTreeG[Item]
+
+func New(degree int) *BTree {
+	return NewWithFreeList(degree, NewFreeList(DefaultFreeListSize))
+}
+
+func NewWithFreeList(degree int, f *FreeList) *BTree {
+	return (*BTree)(NewWithFreeListG(degree, itemLess, (*FreeListG[Item])(f)))
+}
+
+func (t *BTree) g() *BTreeG[Item] {
+	return (*BTreeG[Item])(t)
+}
+
+func (t *BTree) Clone() (t2 *BTree) {
+	return (*BTree)(t.g().Clone())
+}
+
+func (t *BTree) ReplaceOrInsert(item Item) Item {
+	if item == nil {
+		panic("nil item being added to BTree")
+	}
+	out, _ := t.g().ReplaceOrInsert(item)
+	return out
+}
+
+func (t *BTree) Delete(item Item) Item {
+	out, _ := t.g().Delete(item)
+	return out
+}
+
+func (t *BTree) DeleteMin() Item {
+	out, _ := t.g().DeleteMin()
+	return out
+}
+
+func (t *BTree) DeleteMax() Item {
+	out, _ := t.g().DeleteMax()
+	return out
+}
+
+// itemBound makes a nil Item an unbounded end of a range.
+func itemBound(item Item) optional[Item] {
+	return optional[Item]{item: item, valid: item != nil}
+}
+
+func (t *BTree) AscendRange(greaterOrEqual, lessThan Item, iterator ItemIterator) {
+	if t.root == nil {
+		return
+	}
+	t.g().ascend(itemBound(greaterOrEqual), itemBound(lessThan), true, ItemIteratorG[Item](iterator))
+}
+
+func (t *BTree) AscendLessThan(pivot Item, iterator ItemIterator) {
+	t.AscendRange(nil, pivot, iterator)
+}
+
+func (t *BTree) AscendGreaterOrEqual(pivot Item, iterator ItemIterator) {
+	t.AscendRange(pivot, nil, iterator)
+}
+
+func (t *BTree) Ascend(iterator ItemIterator) {
+	t.AscendRange(nil, nil, iterator)
+}
+
+func (t *BTree) DescendRange(lessOrEqual, greaterThan Item, iterator ItemIterator) {
+	if t.root == nil {
+		return
+	}
+	t.g().descend(itemBound(lessOrEqual), itemBound(greaterThan), true, ItemIteratorG[Item](iterator))
+}
+
+func (t *BTree) DescendLessOrEqual(pivot Item, iterator ItemIterator) {
+	t.DescendRange(pivot, nil, iterator)
+}
+
+func (t *BTree) DescendGreaterThan(pivot Item, iterator ItemIterator) {
+	t.DescendRange(nil, pivot, iterator)
+}
+
+func (t *BTree) Descend(iterator ItemIterator) {
+	t.DescendRange(nil, nil, iterator)
+}
+
+func (t *BTree) Get(key Item) Item {
+	out, _ := t.g().Get(key)
+	return out
+}
+
+func (t *BTree) Min() Item {
+	out, _ := t.g().Min()
+	return out
+}
+
+func (t *BTree) Max() Item {
+	out, _ := t.g().Max()
+	return out
+}
+
+func (t *BTree) Has(key Item) bool {
+	return t.g().Has(key)
+}
+
+func (t *BTree) Len() int {
+	return t.g().Len()
+}
+
+func (t *BTree) Clear(addNodesForFreeList bool) {
+	t.g().Clear(addNodesForFreeList)
+}
+
+type Int int
+
+func (a Int) Less(b Item) bool {
+	return a < b.(Int)
+}
@@ -0,0 +1,423 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-22 09:40:12
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-24 17:08:31
+ */
+package bptree
+
+import (
+	"container/list"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// A file is a sequence of pages, page 0 holds the meta data:
+//
+//	magic    [4]byte "BPTD"
+//	version  uint16
+//	_        uint16
+//	pageSize uint32
+//	root     uint64, page of the root node
+//	numPages uint64, number of pages in the file
+//	freeHead uint64, first free page, 0 if none
+//	length   uint64, number of keys
+//	crc      uint32, of the fields above
+//
+// A node page starts with its type and number of keys, then for a leaf:
+//
+//	prev, next uint64, sibling leaves, 0 if none
+//	count times: uvarint key size, key, uvarint value size, value
+//
+// and for an internal node:
+//
+//	child uint64
+//	count times: uvarint key size, key, child uint64
+//
+// Free pages are linked by the next page, stored right after the type.
+const (
+	fileMagic   = "BPTD"
+	fileVersion = 1
+	metaSize    = 48
+
+	pageLeaf     = 1
+	pageInternal = 2
+	pageFree     = 3
+
+	leafHeaderSize     = 19
+	internalHeaderSize = 11
+
+	DefaultPageSize  = 4096
+	DefaultCacheSize = 256
+	MinPageSize      = 512
+)
+
+var (
+	ErrBadFile  = errors.New("not a bptree file")
+	ErrBadPage  = errors.New("corrupted bptree page")
+	ErrTooLarge = errors.New("key and value too large for a page")
+	ErrClosed   = errors.New("bptree file closed")
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+type pageID uint64
+
+// dnode is a node decoded from its page.
+type dnode struct {
+	nodeBody[[]byte, []byte, pageID]
+	id pageID
+	// prev and next link the leaves
+	prev, next pageID
+	dirty      bool
+}
+
+func uvarintSize(x int) int {
+	n := 1
+	for x >= 0x80 {
+		x >>= 7
+		n++
+	}
+	return n
+}
+
+func leafEntrySize(key, value []byte) int {
+	return uvarintSize(len(key)) + len(key) + uvarintSize(len(value)) + len(value)
+}
+
+func internalEntrySize(key []byte) int {
+	return uvarintSize(len(key)) + len(key) + 8
+}
+
+// entrySize is the size of key i in the page.
+func (n *dnode) entrySize(i int) int {
+	if n.leaf() {
+		return leafEntrySize(n.items[i], n.values[i])
+	}
+	return internalEntrySize(n.items[i])
+}
+
+func (n *dnode) size() int {
+	size := internalHeaderSize
+	if n.leaf() {
+		size = leafHeaderSize
+	}
+	for i := range n.items {
+		size += n.entrySize(i)
+	}
+	return size
+}
+
+func (n *dnode) encode(page []byte) {
+	clear(page)
+	binary.BigEndian.PutUint16(page[1:], uint16(len(n.items)))
+	if n.leaf() {
+		page[0] = pageLeaf
+		binary.BigEndian.PutUint64(page[3:], uint64(n.prev))
+		binary.BigEndian.PutUint64(page[11:], uint64(n.next))
+		b := page[leafHeaderSize:leafHeaderSize]
+		for i, key := range n.items {
+			b = binary.AppendUvarint(b, uint64(len(key)))
+			b = append(b, key...)
+			b = binary.AppendUvarint(b, uint64(len(n.values[i])))
+			b = append(b, n.values[i]...)
+		}
+		return
+	}
+	page[0] = pageInternal
+	binary.BigEndian.PutUint64(page[3:], uint64(n.children[0]))
+	b := page[internalHeaderSize:internalHeaderSize]
+	for i, key := range n.items {
+		b = binary.AppendUvarint(b, uint64(len(key)))
+		b = append(b, key...)
+		b = binary.BigEndian.AppendUint64(b, uint64(n.children[i+1]))
+	}
+}
+
+// readBytes returns the uvarint prefixed bytes at the start of b.
+func readBytes(b []byte) (field, rest []byte, ok bool) {
+	size, n := binary.Uvarint(b)
+	if n <= 0 || uint64(len(b)-n) < size {
+		return nil, nil, false
+	}
+	return b[n : n+int(size)], b[n+int(size):], true
+}
+
+func decodeNode(id pageID, page []byte) (*dnode, error) {
+	n := &dnode{id: id}
+	count := int(binary.BigEndian.Uint16(page[1:]))
+	// the node keeps its own copy, the page buffer is reused
+	var b []byte
+	switch page[0] {
+	case pageLeaf:
+		n.prev = pageID(binary.BigEndian.Uint64(page[3:]))
+		n.next = pageID(binary.BigEndian.Uint64(page[11:]))
+		b = append([]byte(nil), page[leafHeaderSize:]...)
+	case pageInternal:
+		n.children = append(n.children, pageID(binary.BigEndian.Uint64(page[3:])))
+		b = append([]byte(nil), page[internalHeaderSize:]...)
+	default:
+		return nil, fmt.Errorf("page %d: %w", id, ErrBadPage)
+	}
+	for i := 0; i < count; i++ {
+		key, rest, ok := readBytes(b)
+		if !ok {
+			return nil, fmt.Errorf("page %d: %w", id, ErrBadPage)
+		}
+		n.items = append(n.items, key[:len(key):len(key)])
+		if page[0] == pageLeaf {
+			var value []byte
+			if value, rest, ok = readBytes(rest); !ok {
+				return nil, fmt.Errorf("page %d: %w", id, ErrBadPage)
+			}
+			n.values = append(n.values, value[:len(value):len(value)])
+		} else {
+			if len(rest) < 8 {
+				return nil, fmt.Errorf("page %d: %w", id, ErrBadPage)
+			}
+			n.children = append(n.children, pageID(binary.BigEndian.Uint64(rest)))
+			rest = rest[8:]
+		}
+		b = rest
+	}
+	return n, nil
+}
+
+type meta struct {
+	pageSize uint32
+	root     pageID
+	numPages uint64
+	freeHead pageID
+	length   uint64
+}
+
+func (m *meta) encode(page []byte) {
+	clear(page)
+	copy(page, fileMagic)
+	binary.BigEndian.PutUint16(page[4:], fileVersion)
+	binary.BigEndian.PutUint32(page[8:], m.pageSize)
+	binary.BigEndian.PutUint64(page[12:], uint64(m.root))
+	binary.BigEndian.PutUint64(page[20:], m.numPages)
+	binary.BigEndian.PutUint64(page[28:], uint64(m.freeHead))
+	binary.BigEndian.PutUint64(page[36:], m.length)
+	binary.BigEndian.PutUint32(page[44:], crc32.Checksum(page[:44], crcTable))
+}
+
+func (m *meta) decode(page []byte) error {
+	if len(page) < metaSize || string(page[:4]) != fileMagic {
+		return ErrBadFile
+	}
+	if crc32.Checksum(page[:44], crcTable) != binary.BigEndian.Uint32(page[44:]) {
+		return ErrBadFile
+	}
+	if v := binary.BigEndian.Uint16(page[4:]); v > fileVersion {
+		return fmt.Errorf("unsupported bptree file version %d", v)
+	}
+	m.pageSize = binary.BigEndian.Uint32(page[8:])
+	m.root = pageID(binary.BigEndian.Uint64(page[12:]))
+	m.numPages = binary.BigEndian.Uint64(page[20:])
+	m.freeHead = pageID(binary.BigEndian.Uint64(page[28:]))
+	m.length = binary.BigEndian.Uint64(page[36:])
+	return nil
+}
+
+// pager reads and writes the pages of a file, keeping the decoded nodes of
+// the last used pages in an LRU cache. Free pages are kept in a list on
+// disk, like the FreeList of the in memory tree, and reused before the file
+// grows.
+//
+// The changed pages of an operation are appended to the write ahead log when
+// it commits, the file itself is only written by checkpoints. Until then the
+// pages are read from the log.
+type pager struct {
+	file      *os.File
+	wal       *wal
+	meta      meta
+	committed meta
+	pageSize  int
+	cacheSize int
+	lru       *list.List
+	cache     map[pageID]*list.Element
+	// dirty has the nodes changed since the last commit, and freed the pages
+	// freed since then, with the next free page
+	dirty []*dnode
+	freed map[pageID]pageID
+	buf   []byte
+}
+
+func newPager(file *os.File, pageSize, cacheSize int) *pager {
+	return &pager{
+		file:      file,
+		pageSize:  pageSize,
+		cacheSize: cacheSize,
+		lru:       list.New(),
+		cache:     make(map[pageID]*list.Element),
+		freed:     make(map[pageID]pageID),
+		buf:       make([]byte, pageSize),
+	}
+}
+
+// readPage reads the last committed image of page id.
+func (p *pager) readPage(id pageID, page []byte) error {
+	if p.wal != nil {
+		if ok, err := p.wal.readImage(id, page); ok {
+			return err
+		}
+	}
+	if _, err := p.file.ReadAt(page, int64(id)*int64(p.pageSize)); err != nil {
+		if err == io.EOF {
+			err = fmt.Errorf("page %d: %w", id, ErrBadPage)
+		}
+		return err
+	}
+	return nil
+}
+
+func (p *pager) writePage(id pageID, page []byte) error {
+	_, err := p.file.WriteAt(page, int64(id)*int64(p.pageSize))
+	return err
+}
+
+// node returns the node of page id.
+func (p *pager) node(id pageID) (*dnode, error) {
+	if e, ok := p.cache[id]; ok {
+		p.lru.MoveToFront(e)
+		return e.Value.(*dnode), nil
+	}
+	if id == 0 || uint64(id) >= p.meta.numPages {
+		return nil, fmt.Errorf("page %d: %w", id, ErrBadPage)
+	}
+	if err := p.readPage(id, p.buf); err != nil {
+		return nil, err
+	}
+	n, err := decodeNode(id, p.buf)
+	if err != nil {
+		return nil, err
+	}
+	p.cache[id] = p.lru.PushFront(n)
+	return n, nil
+}
+
+// markDirty records that n has to be logged at the next commit.
+func (p *pager) markDirty(n *dnode) {
+	if !n.dirty {
+		n.dirty = true
+		p.dirty = append(p.dirty, n)
+	}
+}
+
+// alloc returns a new empty node, on a free page if there is one.
+func (p *pager) alloc() (*dnode, error) {
+	id := p.meta.freeHead
+	if id != 0 {
+		if next, ok := p.freed[id]; ok {
+			// freed by this operation
+			delete(p.freed, id)
+			p.meta.freeHead = next
+		} else {
+			if err := p.readPage(id, p.buf); err != nil {
+				return nil, err
+			}
+			if p.buf[0] != pageFree {
+				return nil, fmt.Errorf("page %d: %w", id, ErrBadPage)
+			}
+			p.meta.freeHead = pageID(binary.BigEndian.Uint64(p.buf[1:]))
+		}
+	} else {
+		id = pageID(p.meta.numPages)
+		p.meta.numPages++
+	}
+	n := &dnode{id: id}
+	p.markDirty(n)
+	p.cache[id] = p.lru.PushFront(n)
+	return n, nil
+}
+
+// free puts the page of n at the head of the free list.
+func (p *pager) free(n *dnode) {
+	if e, ok := p.cache[n.id]; ok {
+		p.lru.Remove(e)
+		delete(p.cache, n.id)
+	}
+	p.freed[n.id] = p.meta.freeHead
+	p.meta.freeHead = n.id
+}
+
+// commit logs the pages changed by an operation, ending with the meta page.
+func (p *pager) commit() error {
+	if len(p.dirty) == 0 && len(p.freed) == 0 && p.meta == p.committed {
+		return nil
+	}
+	var pages []walPage
+	for _, n := range p.dirty {
+		// skip the nodes freed since
+		if e, ok := p.cache[n.id]; ok && e.Value == n {
+			b := make([]byte, p.pageSize)
+			n.encode(b)
+			pages = append(pages, walPage{n.id, b})
+		}
+	}
+	for id, next := range p.freed {
+		b := make([]byte, p.pageSize)
+		b[0] = pageFree
+		binary.BigEndian.PutUint64(b[1:], uint64(next))
+		pages = append(pages, walPage{id, b})
+	}
+	b := make([]byte, p.pageSize)
+	p.meta.encode(b)
+	pages = append(pages, walPage{0, b})
+	if err := p.wal.append(pages); err != nil {
+		return err
+	}
+	for _, n := range p.dirty {
+		n.dirty = false
+	}
+	p.dirty = p.dirty[:0]
+	clear(p.freed)
+	p.committed = p.meta
+	return nil
+}
+
+// rollback drops the changes since the last commit, which are only in
+// memory.
+func (p *pager) rollback() {
+	p.lru.Init()
+	clear(p.cache)
+	p.dirty = p.dirty[:0]
+	clear(p.freed)
+	p.meta = p.committed
+}
+
+// trim evicts the least recently used nodes above the cache size. It is only
+// called after a commit, so the nodes are clean and none is in use.
+func (p *pager) trim() {
+	for p.lru.Len() > p.cacheSize {
+		e := p.lru.Back()
+		p.lru.Remove(e)
+		delete(p.cache, e.Value.(*dnode).id)
+	}
+}
+
+// checkpoint copies the pages logged in the write ahead log to the file, and
+// empties the log.
+func (p *pager) checkpoint() error {
+	if err := p.wal.file.Sync(); err != nil {
+		return err
+	}
+	for id := range p.wal.index {
+		if err := p.readPage(id, p.buf); err != nil {
+			return err
+		}
+		if err := p.writePage(id, p.buf); err != nil {
+			return err
+		}
+	}
+	if err := p.file.Sync(); err != nil {
+		return err
+	}
+	return p.wal.reset()
+}
@@ -0,0 +1,229 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-23 09:18:40
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-23 15:21:06
+ */
+package bptree
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// The write ahead log starts with a header:
+//
+//	magic    [4]byte "BPTW"
+//	version  uint16
+//	_        uint16
+//	pageSize uint32
+//	salt     uint32, changed at every checkpoint
+//	firstLSN uint64, LSN of the first frame
+//	crc      uint32, of the fields above
+//
+// followed by frames, each the new image of a page:
+//
+//	lsn   uint64, one more than the previous frame
+//	page  uint64
+//	flags uint32, walCommit on the last frame of an operation
+//	crc   uint32, of the salt, the fields above and the image
+//	image [pageSize]byte
+//
+// An operation is committed by its last frame, which is always the meta
+// page. Recovery keeps the frames up to the last commit with valid
+// checksums and LSNs, and drops the rest, such as a torn last write.
+const (
+	walMagic       = "BPTW"
+	walVersion     = 1
+	walHeaderSize  = 32
+	walFrameHeader = 24
+	walCommit      = 1
+
+	DefaultCheckpointFrames = 1000
+)
+
+type walPage struct {
+	id    pageID
+	image []byte
+}
+
+type wal struct {
+	file     *os.File
+	pageSize int
+	salt     uint32
+	firstLSN uint64
+	nextLSN  uint64
+	// size is the end of the last committed frame
+	size int64
+	// index has the offset of the last committed frame of every page
+	index  map[pageID]int64
+	frames int
+	buf    []byte
+}
+
+func (w *wal) frameSize() int64 {
+	return int64(walFrameHeader + w.pageSize)
+}
+
+func (w *wal) writeHeader() error {
+	var hdr [walHeaderSize]byte
+	copy(hdr[:], walMagic)
+	binary.BigEndian.PutUint16(hdr[4:], walVersion)
+	binary.BigEndian.PutUint32(hdr[8:], uint32(w.pageSize))
+	binary.BigEndian.PutUint32(hdr[12:], w.salt)
+	binary.BigEndian.PutUint64(hdr[16:], w.firstLSN)
+	binary.BigEndian.PutUint32(hdr[24:], crc32.Checksum(hdr[:24], crcTable))
+	_, err := w.file.WriteAt(hdr[:], 0)
+	return err
+}
+
+// walPageSize reads the page size from the header of a log.
+func walPageSize(path string) (int, bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, false
+	}
+	defer file.Close()
+	var hdr [walHeaderSize]byte
+	if _, err = file.ReadAt(hdr[:], 0); err != nil {
+		return 0, false
+	}
+	if string(hdr[:4]) != walMagic || crc32.Checksum(hdr[:24], crcTable) != binary.BigEndian.Uint32(hdr[24:]) {
+		return 0, false
+	}
+	return int(binary.BigEndian.Uint32(hdr[8:])), true
+}
+
+// openWAL opens the log of a file with pages of pageSize, and recovers its
+// committed frames.
+func openWAL(path string, pageSize int) (*wal, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	w := &wal{
+		file:     file,
+		pageSize: pageSize,
+		index:    make(map[pageID]int64),
+		size:     walHeaderSize,
+		nextLSN:  1,
+	}
+	if err = w.recover(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *wal) recover() error {
+	var hdr [walHeaderSize]byte
+	if _, err := w.file.ReadAt(hdr[:], 0); err != nil {
+		if err != io.EOF {
+			return err
+		}
+		// a new log, or one torn while it was reset
+		return w.reset()
+	}
+	if string(hdr[:4]) != walMagic || binary.BigEndian.Uint16(hdr[4:]) > walVersion ||
+		crc32.Checksum(hdr[:24], crcTable) != binary.BigEndian.Uint32(hdr[24:]) {
+		return w.reset()
+	}
+	if int(binary.BigEndian.Uint32(hdr[8:])) != w.pageSize {
+		return ErrBadFile
+	}
+	w.salt = binary.BigEndian.Uint32(hdr[12:])
+	w.firstLSN = binary.BigEndian.Uint64(hdr[16:])
+	w.nextLSN = w.firstLSN
+
+	frame := make([]byte, w.frameSize())
+	pending := make(map[pageID]int64)
+	lsn := w.firstLSN
+	for off := int64(walHeaderSize); ; off += w.frameSize() {
+		if _, err := w.file.ReadAt(frame, off); err != nil {
+			break
+		}
+		if binary.BigEndian.Uint64(frame) != lsn || w.checksum(frame) != binary.BigEndian.Uint32(frame[20:]) {
+			break
+		}
+		lsn++
+		pending[pageID(binary.BigEndian.Uint64(frame[8:]))] = off
+		if binary.BigEndian.Uint32(frame[16:])&walCommit != 0 {
+			for id, at := range pending {
+				w.index[id] = at
+			}
+			clear(pending)
+			w.size = off + w.frameSize()
+			w.nextLSN = lsn
+			w.frames = int(lsn - w.firstLSN)
+		}
+	}
+	return nil
+}
+
+func (w *wal) checksum(frame []byte) uint32 {
+	var salt [4]byte
+	binary.BigEndian.PutUint32(salt[:], w.salt)
+	crc := crc32.Checksum(salt[:], crcTable)
+	crc = crc32.Update(crc, crcTable, frame[:20])
+	return crc32.Update(crc, crcTable, frame[walFrameHeader:])
+}
+
+// append writes the frames of an operation, the last one commits it.
+func (w *wal) append(pages []walPage) error {
+	w.buf = w.buf[:0]
+	for i, p := range pages {
+		var flags uint32
+		if i == len(pages)-1 {
+			flags = walCommit
+		}
+		start := len(w.buf)
+		w.buf = binary.BigEndian.AppendUint64(w.buf, w.nextLSN+uint64(i))
+		w.buf = binary.BigEndian.AppendUint64(w.buf, uint64(p.id))
+		w.buf = binary.BigEndian.AppendUint32(w.buf, flags)
+		w.buf = append(w.buf, 0, 0, 0, 0)
+		w.buf = append(w.buf, p.image...)
+		frame := w.buf[start:]
+		binary.BigEndian.PutUint32(frame[20:], w.checksum(frame))
+	}
+	if _, err := w.file.WriteAt(w.buf, w.size); err != nil {
+		return err
+	}
+	for i, p := range pages {
+		w.index[p.id] = w.size + int64(i)*w.frameSize()
+	}
+	w.size += int64(len(w.buf))
+	w.nextLSN += uint64(len(pages))
+	w.frames += len(pages)
+	return nil
+}
+
+// readImage reads the last committed image of page id, if it is logged.
+func (w *wal) readImage(id pageID, page []byte) (bool, error) {
+	off, ok := w.index[id]
+	if !ok {
+		return false, nil
+	}
+	_, err := w.file.ReadAt(page, off+walFrameHeader)
+	return true, err
+}
+
+// reset empties the log, after a checkpoint.
+func (w *wal) reset() error {
+	if err := w.file.Truncate(0); err != nil {
+		return err
+	}
+	w.salt++
+	w.firstLSN = w.nextLSN
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	if err := w.file.Sync(); err != nil {
+		return err
+	}
+	w.size = walHeaderSize
+	w.frames = 0
+	clear(w.index)
+	return nil
+}
@@ -0,0 +1,239 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-23 13:40:51
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-24 17:08:31
+ */
+package bptree
+
+import (
+	"maps"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// verifyDisk checks the structure of the tree and that it holds want.
+func verifyDisk(t *testing.T, tr *DiskTree, want map[string]string) {
+	t.Helper()
+	checkDisk(t, tr)
+	got := map[string]string{}
+	if err := tr.AscendRange(nil, nil, func(k, v []byte) bool {
+		got[string(k)] = string(v)
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(got, want) {
+		t.Fatalf("got %v keys, want %v", len(got), len(want))
+	}
+}
+
+// randomOp writes to tr and want the same random change.
+func randomOp(t *testing.T, tr *DiskTree, want map[string]string) {
+	t.Helper()
+	k := diskKey(rand.Intn(400))
+	if rand.Intn(3) == 0 {
+		if _, err := tr.Delete(k); err != nil {
+			t.Fatal(err)
+		}
+		delete(want, string(k))
+		return
+	}
+	v := make([]byte, rand.Intn(80))
+	rand.Read(v)
+	if err := tr.Put(k, v); err != nil {
+		t.Fatal(err)
+	}
+	want[string(k)] = string(v)
+}
+
+// crashCopy writes data and log as the files of a tree in a new directory,
+// as a crash would have left them.
+func crashCopy(t *testing.T, data, log []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tree.db")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+"-wal", log, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readFile(t *testing.T, path string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+var walOpts = Options{PageSize: MinPageSize, CacheSize: 8, CheckpointFrames: 1 << 30}
+
+func TestDiskTreeRecovery(t *testing.T) {
+	tr, path := openTemp(t, walOpts)
+	defer tr.Close()
+	want := map[string]string{}
+	for i := 0; i < 500; i++ {
+		randomOp(t, tr, want)
+	}
+	if err := tr.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	// the file has not been written since it was created
+	crashed, err := OpenWithOptions(crashCopy(t, readFile(t, path), readFile(t, path+"-wal")), walOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyDisk(t, crashed, want)
+	if crashed.pager.wal.size != walHeaderSize {
+		t.Fatalf("recovery should checkpoint the log")
+	}
+	for i := 0; i < 100; i++ {
+		randomOp(t, crashed, want)
+	}
+	if err = crashed.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if crashed, err = OpenWithOptions(crashed.pager.file.Name(), walOpts); err != nil {
+		t.Fatal(err)
+	}
+	defer crashed.Close()
+	verifyDisk(t, crashed, want)
+}
+
+func TestDiskTreeCheckpoint(t *testing.T) {
+	tr, path := openTemp(t, Options{PageSize: MinPageSize, CheckpointFrames: 50})
+	want := map[string]string{}
+	for i := 0; i < 300; i++ {
+		randomOp(t, tr, want)
+		if tr.pager.wal.frames >= 50 {
+			t.Fatalf("log should have been checkpointed")
+		}
+	}
+	if err := tr.Checkpoint(); err != nil {
+		t.Fatal(err)
+	}
+	if tr.pager.wal.size != walHeaderSize {
+		t.Fatalf("log should be empty")
+	}
+	verifyDisk(t, tr, want)
+	if err := tr.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// the file alone holds the tree
+	crashed, err := OpenWithOptions(crashCopy(t, readFile(t, path), nil), walOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer crashed.Close()
+	verifyDisk(t, crashed, want)
+}
+
+// TestDiskTreeWALFaults cuts or corrupts the log at random offsets, and
+// checks that the recovered tree is the one of the last whole operation.
+func TestDiskTreeWALFaults(t *testing.T) {
+	tr, path := openTemp(t, walOpts)
+	defer tr.Close()
+	want := map[string]string{}
+	for i := 0; i < 200; i++ {
+		randomOp(t, tr, want)
+	}
+	if err := tr.Checkpoint(); err != nil {
+		t.Fatal(err)
+	}
+	data := readFile(t, path)
+
+	// the end of the log after every operation, and the tree then
+	ends := []int64{walHeaderSize}
+	states := []map[string]string{maps.Clone(want)}
+	for i := 0; i < 150; i++ {
+		randomOp(t, tr, want)
+		if tr.pager.wal.size != ends[len(ends)-1] {
+			ends = append(ends, tr.pager.wal.size)
+			states = append(states, maps.Clone(want))
+		}
+	}
+	log := readFile(t, path+"-wal")
+	if int64(len(log)) != ends[len(ends)-1] {
+		t.Fatalf("log of %v bytes, ends at %v", len(log), ends[len(ends)-1])
+	}
+	// stateAt is the tree of the operations whole before off
+	stateAt := func(off int64) map[string]string {
+		i := len(ends) - 1
+		for i > 0 && ends[i] > off {
+			i--
+		}
+		return states[i]
+	}
+
+	recover := func(data, log []byte, want map[string]string) {
+		t.Helper()
+		crashed, err := OpenWithOptions(crashCopy(t, data, log), walOpts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		verifyDisk(t, crashed, want)
+		// and it keeps working
+		for i := 0; i < 20; i++ {
+			randomOp(t, crashed, want)
+		}
+		verifyDisk(t, crashed, want)
+		if err = crashed.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	offsets := []int64{0, 1, walHeaderSize, int64(len(log))}
+	for i := 0; i < 60; i++ {
+		offsets = append(offsets, rand.Int63n(int64(len(log))))
+	}
+	for _, off := range offsets {
+		recover(data, log[:off], maps.Clone(stateAt(off)))
+	}
+
+	frameSize := tr.pager.wal.frameSize()
+	for i := 0; i < 20; i++ {
+		off := walHeaderSize + rand.Int63n(int64(len(log))-walHeaderSize)
+		bad := append([]byte(nil), log...)
+		bad[off] ^= 1 << rand.Intn(8)
+		frame := walHeaderSize + (off-walHeaderSize)/frameSize*frameSize
+		recover(data, bad, maps.Clone(stateAt(frame)))
+	}
+
+	// a checkpoint torn while writing the meta page
+	torn := append([]byte(nil), data...)
+	copy(torn[:metaSize], make([]byte, metaSize))
+	recover(torn, log, maps.Clone(want))
+
+	// a crash while creating a file: it is empty until the log of the empty
+	// tree is checkpointed
+	file, err := os.Create(filepath.Join(t.TempDir(), "new.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	p := newPager(file, walOpts.PageSize, 0)
+	if p.wal, err = openWAL(file.Name()+"-wal", walOpts.PageSize); err != nil {
+		t.Fatal(err)
+	}
+	defer p.wal.file.Close()
+	if err = createFile(p); err != nil {
+		t.Fatal(err)
+	}
+	created := readFile(t, file.Name()+"-wal")
+	for _, off := range []int64{0, walHeaderSize, walHeaderSize + frameSize, int64(len(created))} {
+		recover(nil, created[:off], map[string]string{})
+	}
+	if err = p.checkpoint(); err != nil {
+		t.Fatal(err)
+	}
+	page := int(frameSize) - walFrameHeader
+	newData := readFile(t, file.Name())
+	// a checkpoint torn with only the meta page or the root page written
+	recover(newData[:page], created, map[string]string{})
+	recover(append(make([]byte, page), newData[page:]...), created, map[string]string{})
+}
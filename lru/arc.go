@@ -0,0 +1,243 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 14:10:33
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-25 11:20:43
+ */
+
+package lru
+
+import (
+	"errors"
+)
+
+// ARCCache is an Adaptive Replacement Cache. t1 holds entries seen once and
+// t2 entries seen at least twice, b1 and b2 are the ghost lists keeping only
+// the keys recently evicted from t1 and t2. A ghost hit moves the target size
+// p of t1, so the cache adapts between recency and frequency. It is not safe
+// for concurrent use.
+type ARCCache[K comparable, V any] struct {
+	size int
+	p    int
+
+	t1, t2 *lruList[K, V]
+	b1, b2 *lruList[K, V]
+
+	items   map[K]*entry[K, V]
+	onEvict EvictCallback[K, V]
+}
+
+var _ Policy[int, int] = (*ARCCache[int, int])(nil)
+
+func NewARC[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*ARCCache[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	return &ARCCache[K, V]{
+		size:    size,
+		t1:      newList[K, V](),
+		t2:      newList[K, V](),
+		b1:      newList[K, V](),
+		b2:      newList[K, V](),
+		items:   make(map[K]*entry[K, V]),
+		onEvict: onEvict,
+	}, nil
+}
+
+func (c *ARCCache[K, V]) resident(e *entry[K, V]) bool {
+	return e.list == c.t1 || e.list == c.t2
+}
+
+// moveTo moves the entry to the front of l.
+func (c *ARCCache[K, V]) moveTo(e *entry[K, V], l *lruList[K, V]) {
+	if e.list == l {
+		l.moveToFront(e)
+		return
+	}
+	e.list.remove(e)
+	l.insert(e, &l.root)
+}
+
+func (c *ARCCache[K, V]) Get(key K) (value V, ok bool) {
+	if ent, ok := c.items[key]; ok && c.resident(ent) {
+		c.moveTo(ent, c.t2)
+		return ent.value, true
+	}
+	return
+}
+
+// Add adds a value to the cache, returns true if an eviction occurred.
+func (c *ARCCache[K, V]) Add(key K, value V) (evicted bool) {
+	ent, ok := c.items[key]
+	switch {
+	case ok && c.resident(ent):
+		ent.value = value
+		c.moveTo(ent, c.t2)
+		return false
+
+	case ok && ent.list == c.b1:
+		// recency is winning, grow t1
+		delta := 1
+		if c.b1.length() < c.b2.length() {
+			delta = c.b2.length() / c.b1.length()
+		}
+		c.p = min(c.p+delta, c.size)
+		evicted = c.replace(false)
+		ent.value = value
+		c.moveTo(ent, c.t2)
+		return evicted
+
+	case ok && ent.list == c.b2:
+		// frequency is winning, shrink t1
+		delta := 1
+		if c.b2.length() < c.b1.length() {
+			delta = c.b1.length() / c.b2.length()
+		}
+		c.p = max(c.p-delta, 0)
+		evicted = c.replace(true)
+		ent.value = value
+		c.moveTo(ent, c.t2)
+		return evicted
+	}
+
+	if c.t1.length()+c.b1.length() >= c.size {
+		if c.t1.length() < c.size {
+			c.removeGhost(c.b1)
+			evicted = c.replace(false)
+		} else {
+			c.removeElement(c.t1.back())
+			evicted = true
+		}
+	} else if total := len(c.items); total >= c.size {
+		if total >= 2*c.size {
+			c.removeGhost(c.b2)
+		}
+		evicted = c.replace(false)
+	}
+	c.items[key] = c.t1.pushFront(key, value)
+	return evicted
+}
+
+// replace evicts a resident entry into its ghost list once the cache is full.
+func (c *ARCCache[K, V]) replace(inB2 bool) bool {
+	if c.t1.length()+c.t2.length() < c.size {
+		return false
+	}
+	t1 := c.t1.length()
+	if t1 > 0 && (t1 > c.p || (t1 == c.p && inB2)) {
+		c.demote(c.t1.back(), c.b1)
+	} else if c.t2.length() > 0 {
+		c.demote(c.t2.back(), c.b2)
+	} else {
+		c.demote(c.t1.back(), c.b1)
+	}
+	return true
+}
+
+// demote turns a resident entry into a ghost, dropping its value.
+func (c *ARCCache[K, V]) demote(e *entry[K, V], ghost *lruList[K, V]) {
+	key, value := e.key, e.value
+	var zero V
+	e.value = zero
+	c.moveTo(e, ghost)
+	if c.onEvict != nil {
+		c.onEvict(key, value)
+	}
+}
+
+func (c *ARCCache[K, V]) removeGhost(l *lruList[K, V]) {
+	if e := l.back(); e != nil {
+		l.remove(e)
+		delete(c.items, e.key)
+	}
+}
+
+func (c *ARCCache[K, V]) Contains(key K) (ok bool) {
+	ent, ok := c.items[key]
+	return ok && c.resident(ent)
+}
+
+// Peek returns the key value without updating the recent-ness.
+func (c *ARCCache[K, V]) Peek(key K) (value V, ok bool) {
+	if ent, ok := c.items[key]; ok && c.resident(ent) {
+		return ent.value, true
+	}
+	return
+}
+
+// Remove removes the key from the cache, including its ghost entry.
+func (c *ARCCache[K, V]) Remove(key K) (present bool) {
+	ent, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	if !c.resident(ent) {
+		ent.list.remove(ent)
+		delete(c.items, key)
+		return false
+	}
+	c.removeElement(ent)
+	return true
+}
+
+// Keys returns the resident keys, t1 then t2, each from oldest to newest.
+func (c *ARCCache[K, V]) Keys() []K {
+	keys := make([]K, 0, c.Len())
+	for _, l := range []*lruList[K, V]{c.t1, c.t2} {
+		for ent := l.back(); ent != nil; ent = ent.prevEntry() {
+			keys = append(keys, ent.key)
+		}
+	}
+	return keys
+}
+
+func (c *ARCCache[K, V]) Len() int {
+	return c.t1.length() + c.t2.length()
+}
+
+func (c *ARCCache[K, V]) Purge() {
+	for k, v := range c.items {
+		if c.onEvict != nil && c.resident(v) {
+			c.onEvict(k, v.value)
+		}
+		delete(c.items, k)
+	}
+	c.t1.init()
+	c.t2.init()
+	c.b1.init()
+	c.b2.init()
+	c.p = 0
+}
+
+// Resize changes the cache size, returns the number of evicted entries. A
+// size which is not positive is ignored, like NewARC rejects it.
+func (c *ARCCache[K, V]) Resize(size int) (evicted int) {
+	if size <= 0 {
+		return 0
+	}
+	c.size = size
+	c.p = min(c.p, size)
+	for c.Len() > size {
+		l := c.t2
+		if c.t1.length() > c.p || c.t2.length() == 0 {
+			l = c.t1
+		}
+		c.removeElement(l.back())
+		evicted++
+	}
+	for c.t1.length()+c.b1.length() > size && c.b1.length() > 0 {
+		c.removeGhost(c.b1)
+	}
+	for len(c.items) > 2*size {
+		c.removeGhost(c.b2)
+	}
+	return evicted
+}
+
+func (c *ARCCache[K, V]) removeElement(e *entry[K, V]) {
+	e.list.remove(e)
+	delete(c.items, e.key)
+	if c.onEvict != nil {
+		c.onEvict(e.key, e.value)
+	}
+}
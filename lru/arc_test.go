@@ -0,0 +1,135 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 14:40:05
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-25 11:20:43
+ */
+
+package lru
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func checkARC(t *testing.T, c *ARCCache[int, int]) {
+	t.Helper()
+	if n := c.t1.length() + c.t2.length(); n > c.size {
+		t.Fatalf("too many resident entries: %v", n)
+	}
+	if n := c.t1.length() + c.b1.length(); n > c.size {
+		t.Fatalf("t1 + b1 too large: %v", n)
+	}
+	if n := len(c.items); n > 2*c.size {
+		t.Fatalf("too many tracked keys: %v", n)
+	}
+	if c.p < 0 || c.p > c.size {
+		t.Fatalf("bad target p: %v", c.p)
+	}
+}
+
+func TestARCRandomOps(t *testing.T) {
+	size := 128
+	c, err := NewARC[int, int](size, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 200000; i++ {
+		key := rand.Int() % 512
+		switch rand.Int() % 3 {
+		case 0:
+			c.Add(key, key)
+		case 1:
+			if v, ok := c.Get(key); ok && v != key {
+				t.Fatalf("bad value for %v: %v", key, v)
+			}
+		case 2:
+			c.Remove(key)
+		}
+		checkARC(t, c)
+	}
+}
+
+func TestARC(t *testing.T) {
+	evictCounter := 0
+	c, _ := NewARC(128, func(k int, v int) {
+		if k != v {
+			t.Fatalf("Evict values not equal (%v!=%v)", k, v)
+		}
+		evictCounter++
+	})
+	for i := 0; i < 256; i++ {
+		c.Add(i, i)
+	}
+	if c.Len() != 128 || evictCounter != 128 {
+		t.Fatalf("bad len %v or evict count %v", c.Len(), evictCounter)
+	}
+	for i, k := range c.Keys() {
+		if v, ok := c.Get(k); !ok || v != k || v != i+128 {
+			t.Fatalf("bad key: %v", k)
+		}
+	}
+	if c.t1.length() != 0 || c.t2.length() != 128 {
+		t.Fatalf("hits should move every entry to t2")
+	}
+	if !c.Remove(200) || c.Contains(200) {
+		t.Fatalf("200 should be removed")
+	}
+	c.Purge()
+	if c.Len() != 0 || len(c.items) != 0 {
+		t.Fatalf("cache should be empty")
+	}
+}
+
+// A ghost hit in b1 grows the target size of t1.
+func TestARCAdaptive(t *testing.T) {
+	c, _ := NewARC[int, int](4, nil)
+	for i := 0; i < 4; i++ {
+		c.Add(i, i)
+	}
+	c.Get(0)
+	c.Get(1)
+	c.Add(4, 4)
+	c.Add(5, 5)
+	if c.b1.length() == 0 {
+		t.Fatalf("evicted entries should be kept in b1")
+	}
+	if c.Contains(2) {
+		t.Fatalf("2 should have been evicted")
+	}
+	c.Add(2, 2)
+	if c.p == 0 {
+		t.Fatalf("b1 hit should have increased p")
+	}
+	if !c.Contains(2) || c.items[2].list != c.t2 {
+		t.Fatalf("2 should be back in t2")
+	}
+
+	// a scan of keys seen once only churns t1
+	t2 := c.t2.length()
+	for i := 100; i < 200; i++ {
+		c.Add(i, i)
+	}
+	if c.t2.length() < t2-1 || !c.Contains(2) {
+		t.Fatalf("frequent keys should survive the scan: %v", c.Keys())
+	}
+	checkARC(t, c)
+}
+
+func TestARCResize(t *testing.T) {
+	c, _ := NewARC[int, int](8, nil)
+	for i := 0; i < 16; i++ {
+		c.Add(i, i)
+		c.Get(i / 2)
+	}
+	if evicted := c.Resize(4); evicted != 4 || c.Len() != 4 {
+		t.Fatalf("resize should evict 4 entries: %v, len %v", evicted, c.Len())
+	}
+	checkARC(t, c)
+	for _, size := range []int{0, -1} {
+		if evicted := c.Resize(size); evicted != 0 || c.Len() != 4 || c.size != 4 {
+			t.Fatalf("resize to %v should be ignored: len %v", size, c.Len())
+		}
+	}
+	checkARC(t, c)
+}
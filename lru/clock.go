@@ -0,0 +1,191 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 13:22:49
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-24 09:47:18
+ */
+
+package lru
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// clockValue is a value with its reference bit.
+type clockValue[V any] struct {
+	value      V
+	referenced atomic.Bool
+}
+
+// ClockCache approximates LRU with the CLOCK (second chance) algorithm. A hit
+// only sets the reference bit of the entry, so Get runs under a read lock and
+// concurrent lookups do not serialize. It is safe for concurrent use.
+type ClockCache[K comparable, V any] struct {
+	lock    sync.RWMutex
+	size    int
+	ring    *lruList[K, clockValue[V]]
+	hand    *entry[K, clockValue[V]]
+	items   map[K]*entry[K, clockValue[V]]
+	onEvict EvictCallback[K, V]
+}
+
+var _ Policy[int, int] = (*ClockCache[int, int])(nil)
+
+func NewClock[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*ClockCache[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	return &ClockCache[K, V]{
+		size:    size,
+		ring:    newList[K, clockValue[V]](),
+		items:   make(map[K]*entry[K, clockValue[V]]),
+		onEvict: onEvict,
+	}, nil
+}
+
+// advance returns the entry after e on the ring, wrapping past the root.
+func (c *ClockCache[K, V]) advance(e *entry[K, clockValue[V]]) *entry[K, clockValue[V]] {
+	if next := e.nextEntry(); next != nil {
+		return next
+	}
+	return c.ring.front()
+}
+
+// Add adds a value to the cache, returns true if an eviction occurred.
+func (c *ClockCache[K, V]) Add(key K, value V) (evicted bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if ent, ok := c.items[key]; ok {
+		ent.value.value = value
+		ent.value.referenced.Store(true)
+		return false
+	}
+
+	if c.ring.length() >= c.size {
+		c.evict()
+		evicted = true
+	}
+	// new entries are placed right behind the hand, so they get a full turn
+	// before they are examined
+	var ent *entry[K, clockValue[V]]
+	if c.hand == nil {
+		ent = c.ring.pushBack(key, clockValue[V]{value: value})
+		c.hand = ent
+	} else {
+		ent = c.ring.insertValue(key, clockValue[V]{value: value}, c.hand.prev)
+	}
+	c.items[key] = ent
+	return evicted
+}
+
+func (c *ClockCache[K, V]) Get(key K) (value V, ok bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if ent, ok := c.items[key]; ok {
+		ent.value.referenced.Store(true)
+		return ent.value.value, true
+	}
+	return
+}
+
+func (c *ClockCache[K, V]) Contains(key K) (ok bool) {
+	c.lock.RLock()
+	_, ok = c.items[key]
+	c.lock.RUnlock()
+	return ok
+}
+
+// Peek returns the key value without setting the reference bit.
+func (c *ClockCache[K, V]) Peek(key K) (value V, ok bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if ent, ok := c.items[key]; ok {
+		return ent.value.value, true
+	}
+	return
+}
+
+func (c *ClockCache[K, V]) Remove(key K) (present bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if ent, ok := c.items[key]; ok {
+		c.removeElement(ent)
+		return true
+	}
+	return false
+}
+
+// Keys returns a slice of the keys in the cache, starting at the hand.
+func (c *ClockCache[K, V]) Keys() []K {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	keys := make([]K, 0, c.ring.length())
+	for i, ent := 0, c.hand; i < c.ring.length(); i, ent = i+1, c.advance(ent) {
+		keys = append(keys, ent.key)
+	}
+	return keys
+}
+
+func (c *ClockCache[K, V]) Len() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.ring.length()
+}
+
+func (c *ClockCache[K, V]) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for k, v := range c.items {
+		if c.onEvict != nil {
+			c.onEvict(k, v.value.value)
+		}
+		delete(c.items, k)
+	}
+	c.ring.init()
+	c.hand = nil
+}
+
+// Resize changes the cache size, returns the number of evicted entries.
+func (c *ClockCache[K, V]) Resize(size int) (evicted int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	diff := c.ring.length() - size
+	if diff < 0 {
+		diff = 0
+	}
+	for i := 0; i < diff; i++ {
+		c.evict()
+	}
+	c.size = size
+	return diff
+}
+
+// evict sweeps the hand, clearing reference bits, until it finds an entry
+// without one and removes it.
+func (c *ClockCache[K, V]) evict() {
+	if c.hand == nil {
+		return
+	}
+	for c.hand.value.referenced.Load() {
+		c.hand.value.referenced.Store(false)
+		c.hand = c.advance(c.hand)
+	}
+	c.removeElement(c.hand)
+}
+
+func (c *ClockCache[K, V]) removeElement(e *entry[K, clockValue[V]]) {
+	if e == c.hand {
+		c.hand = c.advance(e)
+	}
+	c.ring.remove(e)
+	if c.ring.length() == 0 {
+		c.hand = nil
+	}
+	delete(c.items, e.key)
+	if c.onEvict != nil {
+		c.onEvict(e.key, e.value.value)
+	}
+}
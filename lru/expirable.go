@@ -0,0 +1,268 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 18:02:44
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-24 09:47:18
+ */
+
+package lru
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// numBuckets is the number of expiry buckets the janitor walks through, one
+// per ttl/numBuckets.
+const numBuckets = 100
+
+// expiringValue is a value with its deadline, and the janitor bucket it is
+// in.
+type expiringValue[V any] struct {
+	value     V
+	expiresAt time.Time
+	bucket    uint8
+}
+
+// ExpirableCache is a LRU cache where every entry carries a deadline of ttl
+// after its last Add. Expired entries are misses and are dropped lazily on
+// access. With a janitor, entries are also kept in time ordered buckets and a
+// background goroutine purges one bucket per ttl/numBuckets, so expired
+// entries do not hold memory. It is safe for concurrent use.
+type ExpirableCache[K comparable, V any] struct {
+	mu        sync.Mutex
+	size      int
+	ttl       time.Duration
+	evictList *lruList[K, expiringValue[V]]
+	items     map[K]*entry[K, expiringValue[V]]
+	onEvict   EvictReasonCallback[K, V]
+
+	// buckets[i] holds the entries added while nextCleanupBucket was i
+	buckets           []map[K]*entry[K, expiringValue[V]]
+	nextCleanupBucket uint8
+
+	now       func() time.Time
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+var _ Policy[int, int] = (*ExpirableCache[int, int])(nil)
+
+// NewExpirable creates an expirable cache without a janitor, expired entries
+// are only removed when they are looked up or reach the back of the list.
+func NewExpirable[K comparable, V any](size int, ttl time.Duration, onEvict EvictReasonCallback[K, V]) (*ExpirableCache[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	if ttl <= 0 {
+		return nil, errors.New("must provide a positive ttl")
+	}
+	c := &ExpirableCache[K, V]{
+		size:      size,
+		ttl:       ttl,
+		evictList: newList[K, expiringValue[V]](),
+		items:     make(map[K]*entry[K, expiringValue[V]]),
+		onEvict:   onEvict,
+		buckets:   make([]map[K]*entry[K, expiringValue[V]], numBuckets),
+		now:       time.Now,
+		done:      make(chan struct{}),
+	}
+	for i := range c.buckets {
+		c.buckets[i] = make(map[K]*entry[K, expiringValue[V]])
+	}
+	return c, nil
+}
+
+// NewExpirableWithJanitor creates an expirable cache and starts the janitor
+// goroutine, Close must be called to stop it.
+func NewExpirableWithJanitor[K comparable, V any](size int, ttl time.Duration, onEvict EvictReasonCallback[K, V]) (*ExpirableCache[K, V], error) {
+	c, err := NewExpirable(size, ttl, onEvict)
+	if err != nil {
+		return nil, err
+	}
+	go c.janitor()
+	return c, nil
+}
+
+func (c *ExpirableCache[K, V]) janitor() {
+	// a ttl under numBuckets nanoseconds still needs a positive interval
+	ticker := time.NewTicker(max(c.ttl/numBuckets, 1))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.deleteExpired()
+		}
+	}
+}
+
+// Close stops the janitor, it is safe to call more than once.
+func (c *ExpirableCache[K, V]) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+// deleteExpired purges the expired entries of the next bucket. An entry is
+// visited right after it was added and again one ttl later, when it is due.
+func (c *ExpirableCache[K, V]) deleteExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	idx := c.nextCleanupBucket
+	now := c.now()
+	for _, ent := range c.buckets[idx] {
+		if !now.Before(ent.value.expiresAt) {
+			c.removeElement(ent, EvictExpired)
+		}
+	}
+	c.nextCleanupBucket = (idx + 1) % numBuckets
+}
+
+func (c *ExpirableCache[K, V]) expired(e *entry[K, expiringValue[V]], now time.Time) bool {
+	return !now.Before(e.value.expiresAt)
+}
+
+// Add adds a value to the cache, returns true if an eviction occurred.
+// Updating an existing key resets its deadline.
+func (c *ExpirableCache[K, V]) Add(key K, value V) (evicted bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+
+	if ent, ok := c.items[key]; ok {
+		c.evictList.moveToFront(ent)
+		delete(c.buckets[ent.value.bucket], key)
+		ent.value.value = value
+		c.schedule(ent, now)
+		return false
+	}
+
+	ent := c.evictList.pushFront(key, expiringValue[V]{value: value})
+	c.items[key] = ent
+	c.schedule(ent, now)
+
+	evict := c.evictList.length() > c.size
+	if evict {
+		c.removeOldest(now)
+	}
+	return evict
+}
+
+func (c *ExpirableCache[K, V]) schedule(e *entry[K, expiringValue[V]], now time.Time) {
+	e.value.expiresAt = now.Add(c.ttl)
+	e.value.bucket = c.nextCleanupBucket
+	c.buckets[e.value.bucket][e.key] = e
+}
+
+// Get returns the value of an unexpired key. Expired keys are removed.
+func (c *ExpirableCache[K, V]) Get(key K) (value V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ent, ok := c.items[key]; ok {
+		if c.expired(ent, c.now()) {
+			c.removeElement(ent, EvictExpired)
+			return value, false
+		}
+		c.evictList.moveToFront(ent)
+		return ent.value.value, true
+	}
+	return
+}
+
+func (c *ExpirableCache[K, V]) Contains(key K) (ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ent, ok := c.items[key]
+	return ok && !c.expired(ent, c.now())
+}
+
+// Peek returns the value of an unexpired key without updating the
+// recent-ness.
+func (c *ExpirableCache[K, V]) Peek(key K) (value V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ent, ok := c.items[key]; ok && !c.expired(ent, c.now()) {
+		return ent.value.value, true
+	}
+	return
+}
+
+func (c *ExpirableCache[K, V]) Remove(key K) (present bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ent, ok := c.items[key]; ok {
+		c.removeElement(ent, EvictRemoved)
+		return true
+	}
+	return false
+}
+
+// Keys returns the unexpired keys, from oldest to newest.
+func (c *ExpirableCache[K, V]) Keys() []K {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	keys := make([]K, 0, c.evictList.length())
+	for ent := c.evictList.back(); ent != nil; ent = ent.prevEntry() {
+		if !c.expired(ent, now) {
+			keys = append(keys, ent.key)
+		}
+	}
+	return keys
+}
+
+// Len returns the number of entries, including expired ones not yet purged.
+func (c *ExpirableCache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.evictList.length()
+}
+
+func (c *ExpirableCache[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ent := range c.items {
+		c.removeElement(ent, EvictRemoved)
+	}
+	c.evictList.init()
+}
+
+// Resize changes the cache size, returns the number of evicted entries.
+func (c *ExpirableCache[K, V]) Resize(size int) (evicted int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	diff := c.evictList.length() - size
+	if diff < 0 {
+		diff = 0
+	}
+	for i := 0; i < diff; i++ {
+		c.removeOldest(now)
+	}
+	c.size = size
+	return diff
+}
+
+// removeOldest evicts the back of the list, reporting it as expired if its
+// deadline has passed anyway.
+func (c *ExpirableCache[K, V]) removeOldest(now time.Time) {
+	if ent := c.evictList.back(); ent != nil {
+		reason := EvictCapacity
+		if c.expired(ent, now) {
+			reason = EvictExpired
+		}
+		c.removeElement(ent, reason)
+	}
+}
+
+func (c *ExpirableCache[K, V]) removeElement(e *entry[K, expiringValue[V]], reason EvictReason) {
+	c.evictList.remove(e)
+	delete(c.items, e.key)
+	delete(c.buckets[e.value.bucket], e.key)
+	if c.onEvict != nil {
+		c.onEvict(e.key, e.value.value, reason)
+	}
+}
@@ -0,0 +1,141 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 18:41:26
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-24 09:47:18
+ */
+
+package lru
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (f *fakeClock) now() time.Time {
+	return f.t
+}
+
+func (f *fakeClock) advance(d time.Duration) {
+	f.t = f.t.Add(d)
+}
+
+func TestExpirable(t *testing.T) {
+	reasons := map[int]EvictReason{}
+	c, err := NewExpirable(3, time.Second, func(k int, v int, r EvictReason) {
+		reasons[k] = r
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	c.now = clock.now
+
+	c.Add(1, 1)
+	c.Add(2, 2)
+	clock.advance(500 * time.Millisecond)
+	c.Add(3, 3)
+	c.Add(1, 1) // resets the deadline of 1
+	if v, ok := c.Get(2); !ok || v != 2 {
+		t.Fatalf("2 should not have expired yet")
+	}
+
+	clock.advance(600 * time.Millisecond)
+	if _, ok := c.Get(2); ok {
+		t.Fatalf("2 should have expired")
+	}
+	if got, want := c.Keys(), []int{3, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys: got %v, want %v", got, want)
+	}
+	if c.Contains(2) || !c.Contains(3) {
+		t.Fatalf("only unexpired keys should be contained")
+	}
+
+	c.Add(4, 4)
+	c.Add(5, 5)
+	if got, want := c.Keys(), []int{1, 4, 5}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys: got %v, want %v", got, want)
+	}
+	c.Remove(4)
+
+	want := map[int]EvictReason{2: EvictExpired, 3: EvictCapacity, 4: EvictRemoved}
+	if !reflect.DeepEqual(reasons, want) {
+		t.Fatalf("reasons: got %v, want %v", reasons, want)
+	}
+	if EvictExpired.String() != "expired" {
+		t.Fatalf("bad reason string: %v", EvictExpired)
+	}
+}
+
+func TestExpirableBuckets(t *testing.T) {
+	var expired []int
+	c, _ := NewExpirable(10, time.Second, func(k int, v int, r EvictReason) {
+		if r == EvictExpired {
+			expired = append(expired, k)
+		}
+	})
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	c.now = clock.now
+	tick := time.Second / numBuckets
+
+	c.Add(1, 1)
+	for i := 0; i <= numBuckets; i++ {
+		clock.advance(tick)
+		c.deleteExpired()
+		if i == numBuckets/2 {
+			c.Add(2, 2)
+		}
+	}
+	// 1 is purged when the janitor comes back to its bucket, at most one
+	// tick after its deadline. 2 is not due yet.
+	if !reflect.DeepEqual(expired, []int{1}) || c.Len() != 1 {
+		t.Fatalf("expired: got %v, len %v", expired, c.Len())
+	}
+	for i := 0; i < numBuckets; i++ {
+		clock.advance(tick)
+		c.deleteExpired()
+	}
+	if !reflect.DeepEqual(expired, []int{1, 2}) || c.Len() != 0 {
+		t.Fatalf("expired: got %v, len %v", expired, c.Len())
+	}
+}
+
+func TestExpirableJanitor(t *testing.T) {
+	// a ttl under numBuckets nanoseconds must not stop the janitor ticker
+	for _, ttl := range []time.Duration{50 * time.Millisecond, 50 * time.Nanosecond} {
+		done := make(chan int, 1)
+		c, err := NewExpirableWithJanitor(10, ttl, func(k int, v int, r EvictReason) {
+			if r == EvictExpired {
+				done <- k
+			}
+		})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		c.Add(1, 1)
+		select {
+		case k := <-done:
+			if k != 1 || c.Len() != 0 {
+				t.Fatalf("ttl %v: bad expiry: %v, len %v", ttl, k, c.Len())
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("ttl %v: janitor should have purged 1", ttl)
+		}
+		c.Close()
+	}
+}
+
+func TestExpirableInvalidParams(t *testing.T) {
+	if _, err := NewExpirable[int, int](1, 0, nil); err == nil {
+		t.Fatalf("expected error for zero ttl")
+	}
+	if _, err := NewExpirable[int, int](0, time.Second, nil); err == nil {
+		t.Fatalf("expected error for zero size")
+	}
+}
@@ -0,0 +1,133 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 13:05:18
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-18 13:40:27
+ */
+
+package lru
+
+import (
+	"errors"
+)
+
+// FIFOCache evicts entries in insertion order, hits never reorder the list.
+// It is not safe for concurrent use.
+type FIFOCache[K comparable, V any] struct {
+	size      int
+	evictList *lruList[K, V]
+	items     map[K]*entry[K, V]
+	onEvict   EvictCallback[K, V]
+}
+
+var _ Policy[int, int] = (*FIFOCache[int, int])(nil)
+
+func NewFIFO[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*FIFOCache[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	return &FIFOCache[K, V]{
+		size:      size,
+		evictList: newList[K, V](),
+		items:     make(map[K]*entry[K, V]),
+		onEvict:   onEvict,
+	}, nil
+}
+
+// Add adds a value to the cache, returns true if an eviction occurred.
+// Updating an existing key keeps its position.
+func (c *FIFOCache[K, V]) Add(key K, value V) (evicted bool) {
+	if ent, ok := c.items[key]; ok {
+		ent.value = value
+		return false
+	}
+
+	c.items[key] = c.evictList.pushFront(key, value)
+	evict := c.evictList.length() > c.size
+	if evict {
+		c.removeOldest()
+	}
+	return evict
+}
+
+func (c *FIFOCache[K, V]) Get(key K) (value V, ok bool) {
+	return c.Peek(key)
+}
+
+func (c *FIFOCache[K, V]) Contains(key K) (ok bool) {
+	_, ok = c.items[key]
+	return ok
+}
+
+func (c *FIFOCache[K, V]) Peek(key K) (value V, ok bool) {
+	if ent, ok := c.items[key]; ok {
+		return ent.value, true
+	}
+	return
+}
+
+func (c *FIFOCache[K, V]) Remove(key K) (present bool) {
+	if ent, ok := c.items[key]; ok {
+		c.removeElement(ent)
+		return true
+	}
+	return false
+}
+
+func (c *FIFOCache[K, V]) RemoveOldest() (key K, value V, ok bool) {
+	if ent := c.evictList.back(); ent != nil {
+		c.removeElement(ent)
+		return ent.key, ent.value, true
+	}
+	return
+}
+
+// Keys returns a slice of the keys in the cache, from oldest to newest.
+func (c *FIFOCache[K, V]) Keys() []K {
+	keys := make([]K, 0, c.evictList.length())
+	for ent := c.evictList.back(); ent != nil; ent = ent.prevEntry() {
+		keys = append(keys, ent.key)
+	}
+	return keys
+}
+
+func (c *FIFOCache[K, V]) Len() int {
+	return c.evictList.length()
+}
+
+func (c *FIFOCache[K, V]) Purge() {
+	for k, v := range c.items {
+		if c.onEvict != nil {
+			c.onEvict(k, v.value)
+		}
+		delete(c.items, k)
+	}
+	c.evictList.init()
+}
+
+// Resize changes the cache size, returns the number of evicted entries.
+func (c *FIFOCache[K, V]) Resize(size int) (evicted int) {
+	diff := c.Len() - size
+	if diff < 0 {
+		diff = 0
+	}
+	for i := 0; i < diff; i++ {
+		c.removeOldest()
+	}
+	c.size = size
+	return diff
+}
+
+func (c *FIFOCache[K, V]) removeOldest() {
+	if ent := c.evictList.back(); ent != nil {
+		c.removeElement(ent)
+	}
+}
+
+func (c *FIFOCache[K, V]) removeElement(e *entry[K, V]) {
+	c.evictList.remove(e)
+	delete(c.items, e.key)
+	if c.onEvict != nil {
+		c.onEvict(e.key, e.value)
+	}
+}
@@ -0,0 +1,117 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 13:31:02
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-18 13:40:27
+ */
+
+package lru
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestFIFO(t *testing.T) {
+	var evicted []int
+	l, err := NewFIFO(2, func(k int, v int) { evicted = append(evicted, k) })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, 1)
+	l.Add(2, 2)
+	// hits do not save 1 from eviction
+	l.Get(1)
+	l.Add(1, 10)
+	if !l.Add(3, 3) {
+		t.Fatalf("adding 3 should evict")
+	}
+	if got, want := l.Keys(), []int{2, 3}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys: got %v, want %v", got, want)
+	}
+	if k, _, ok := l.RemoveOldest(); !ok || k != 2 {
+		t.Fatalf("oldest should be 2: %v", k)
+	}
+	if got, want := evicted, []int{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("evicted: got %v, want %v", got, want)
+	}
+	if evicted := l.Resize(0); evicted != 1 || l.Len() != 0 {
+		t.Fatalf("resize should evict everything: %v", evicted)
+	}
+}
+
+func TestClock(t *testing.T) {
+	var evicted []int
+	l, err := NewClock(3, func(k int, v int) { evicted = append(evicted, k) })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3)
+	if got, want := l.Keys(), []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys: got %v, want %v", got, want)
+	}
+
+	// 1 gets a second chance, 2 is evicted
+	l.Get(1)
+	l.Add(4, 4)
+	if got, want := l.Keys(), []int{3, 1, 4}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys: got %v, want %v", got, want)
+	}
+	// every bit set, the hand makes a full turn and evicts 3
+	l.Get(1)
+	l.Get(3)
+	l.Get(4)
+	l.Add(5, 5)
+	if got, want := l.Keys(), []int{1, 4, 5}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys: got %v, want %v", got, want)
+	}
+	if v, ok := l.Peek(5); !ok || v != 5 {
+		t.Fatalf("bad value for 5: %v", v)
+	}
+	if !l.Remove(1) || l.Contains(1) {
+		t.Fatalf("1 should be removed")
+	}
+	if got, want := evicted, []int{2, 3, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("evicted: got %v, want %v", got, want)
+	}
+	if evicted := l.Resize(1); evicted != 1 || l.Len() != 1 {
+		t.Fatalf("resize should evict 1 element: %v", evicted)
+	}
+	l.Purge()
+	if l.Len() != 0 || len(l.Keys()) != 0 {
+		t.Fatalf("cache should be empty")
+	}
+	l.Add(6, 6)
+	if !l.Contains(6) {
+		t.Fatalf("6 should be contained")
+	}
+}
+
+func TestClockConcurrent(t *testing.T) {
+	l, _ := NewClock[int, int](64, nil)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				k := (i * (g + 1)) % 128
+				if _, ok := l.Get(k); !ok {
+					l.Add(k, k)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if l.Len() > 64 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+}
+
+func BenchmarkClockParallel(b *testing.B) {
+	c, _ := NewClock[int, int](8192, nil)
+	benchmarkParallel(b, c)
+}
@@ -0,0 +1,210 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 11:20:36
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-24 09:47:18
+ */
+
+package lru
+
+import (
+	"errors"
+)
+
+// lfuBucket holds the entries sharing one access count, most recent first.
+// Buckets are linked in ascending count order.
+type lfuBucket[K comparable, V any] struct {
+	next, prev *lfuBucket[K, V]
+	freq       int
+	entries    lruList[K, lfuValue[K, V]]
+}
+
+// lfuValue is a value with the bucket of its access count.
+type lfuValue[K comparable, V any] struct {
+	value  V
+	bucket *lfuBucket[K, V]
+}
+
+// LFUCache is a fixed size LFU cache with O(1) operations. Ties between
+// entries with the same access count are broken by evicting the least
+// recently used one. It is not safe for concurrent use.
+type LFUCache[K comparable, V any] struct {
+	size    int
+	root    lfuBucket[K, V]
+	items   map[K]*entry[K, lfuValue[K, V]]
+	onEvict EvictCallback[K, V]
+}
+
+var _ Policy[int, int] = (*LFUCache[int, int])(nil)
+
+func NewLFU[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*LFUCache[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	c := &LFUCache[K, V]{
+		size:    size,
+		items:   make(map[K]*entry[K, lfuValue[K, V]]),
+		onEvict: onEvict,
+	}
+	c.root.next = &c.root
+	c.root.prev = &c.root
+	return c, nil
+}
+
+// bucketAfter returns the bucket for freq, creating it right after at.
+func (c *LFUCache[K, V]) bucketAfter(at *lfuBucket[K, V], freq int) *lfuBucket[K, V] {
+	if b := at.next; b != &c.root && b.freq == freq {
+		return b
+	}
+	b := &lfuBucket[K, V]{freq: freq, prev: at, next: at.next}
+	b.entries.init()
+	at.next.prev = b
+	at.next = b
+	return b
+}
+
+func (c *LFUCache[K, V]) unlink(e *entry[K, lfuValue[K, V]]) {
+	b := e.value.bucket
+	b.entries.remove(e)
+	e.value.bucket = nil
+	if b.entries.length() == 0 {
+		b.prev.next = b.next
+		b.next.prev = b.prev
+	}
+}
+
+// touch moves the entry into the bucket of the next access count.
+func (c *LFUCache[K, V]) touch(e *entry[K, lfuValue[K, V]]) {
+	b := e.value.bucket
+	next := c.bucketAfter(b, b.freq+1)
+	c.unlink(e)
+	next.entries.insert(e, &next.entries.root)
+	e.value.bucket = next
+}
+
+// Add adds a value to the cache, returns true if an eviction occurred.
+// Updating an existing key counts as an access.
+func (c *LFUCache[K, V]) Add(key K, value V) (evicted bool) {
+	if ent, ok := c.items[key]; ok {
+		ent.value.value = value
+		c.touch(ent)
+		return false
+	}
+
+	if len(c.items) >= c.size {
+		c.removeLeastFrequent()
+		evicted = true
+	}
+	b := c.bucketAfter(&c.root, 1)
+	ent := b.entries.pushFront(key, lfuValue[K, V]{value: value})
+	ent.value.bucket = b
+	c.items[key] = ent
+	return evicted
+}
+
+func (c *LFUCache[K, V]) Get(key K) (value V, ok bool) {
+	if ent, ok := c.items[key]; ok {
+		c.touch(ent)
+		return ent.value.value, true
+	}
+	return
+}
+
+// Contains checks if a key is in the cache, without updating the access count.
+func (c *LFUCache[K, V]) Contains(key K) (ok bool) {
+	_, ok = c.items[key]
+	return ok
+}
+
+// Peek returns the key value without updating the access count.
+func (c *LFUCache[K, V]) Peek(key K) (value V, ok bool) {
+	if ent, ok := c.items[key]; ok {
+		return ent.value.value, true
+	}
+	return
+}
+
+// Frequency returns the access count of a key, 0 if it is not cached.
+func (c *LFUCache[K, V]) Frequency(key K) int {
+	if ent, ok := c.items[key]; ok {
+		return ent.value.bucket.freq
+	}
+	return 0
+}
+
+func (c *LFUCache[K, V]) Remove(key K) (present bool) {
+	if ent, ok := c.items[key]; ok {
+		c.removeElement(ent)
+		return true
+	}
+	return false
+}
+
+// RemoveLeastFrequent removes the entry that would be evicted next.
+func (c *LFUCache[K, V]) RemoveLeastFrequent() (key K, value V, ok bool) {
+	if ent := c.leastFrequent(); ent != nil {
+		c.removeElement(ent)
+		return ent.key, ent.value.value, true
+	}
+	return
+}
+
+// Keys returns a slice of the keys in the cache, in eviction order.
+func (c *LFUCache[K, V]) Keys() []K {
+	keys := make([]K, 0, len(c.items))
+	for b := c.root.next; b != &c.root; b = b.next {
+		for ent := b.entries.back(); ent != nil; ent = ent.prevEntry() {
+			keys = append(keys, ent.key)
+		}
+	}
+	return keys
+}
+
+func (c *LFUCache[K, V]) Len() int {
+	return len(c.items)
+}
+
+func (c *LFUCache[K, V]) Purge() {
+	for k, v := range c.items {
+		if c.onEvict != nil {
+			c.onEvict(k, v.value.value)
+		}
+		delete(c.items, k)
+	}
+	c.root.next = &c.root
+	c.root.prev = &c.root
+}
+
+// Resize changes the cache size, returns the number of evicted entries.
+func (c *LFUCache[K, V]) Resize(size int) (evicted int) {
+	diff := c.Len() - size
+	if diff < 0 {
+		diff = 0
+	}
+	for i := 0; i < diff; i++ {
+		c.removeLeastFrequent()
+	}
+	c.size = size
+	return diff
+}
+
+func (c *LFUCache[K, V]) leastFrequent() *entry[K, lfuValue[K, V]] {
+	if b := c.root.next; b != &c.root {
+		return b.entries.back()
+	}
+	return nil
+}
+
+func (c *LFUCache[K, V]) removeLeastFrequent() {
+	if ent := c.leastFrequent(); ent != nil {
+		c.removeElement(ent)
+	}
+}
+
+func (c *LFUCache[K, V]) removeElement(e *entry[K, lfuValue[K, V]]) {
+	c.unlink(e)
+	delete(c.items, e.key)
+	if c.onEvict != nil {
+		c.onEvict(e.key, e.value.value)
+	}
+}
@@ -0,0 +1,90 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 11:41:52
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-18 11:52:10
+ */
+
+package lru
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLFU(t *testing.T) {
+	var evicted []int
+	l, err := NewLFU(3, func(k int, v int) { evicted = append(evicted, k) })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3)
+	l.Get(1)
+	l.Get(1)
+	l.Get(2)
+	// 3 has the lowest access count
+	if !l.Add(4, 4) {
+		t.Fatalf("adding 4 should evict")
+	}
+	if l.Contains(3) {
+		t.Fatalf("3 should have been evicted")
+	}
+	if f := l.Frequency(1); f != 3 {
+		t.Fatalf("bad frequency for 1: %v", f)
+	}
+	if got, want := l.Keys(), []int{4, 2, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys: got %v, want %v", got, want)
+	}
+
+	// 2 and 4 tie on count, 2 is the least recent
+	l.Get(4)
+	l.Add(5, 5)
+	l.Get(5)
+	if got, want := l.Keys(), []int{4, 5, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys: got %v, want %v", got, want)
+	}
+	if got, want := evicted, []int{3, 2}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("evicted: got %v, want %v", got, want)
+	}
+
+	if v, ok := l.Peek(4); !ok || v != 4 || l.Frequency(4) != 2 {
+		t.Fatalf("Peek should not count as an access")
+	}
+	if k, _, ok := l.RemoveLeastFrequent(); !ok || k != 4 {
+		t.Fatalf("least frequent should be 4: %v", k)
+	}
+	if !l.Remove(5) || l.Remove(5) {
+		t.Fatalf("5 should be removed exactly once")
+	}
+	if l.Len() != 1 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+
+	l.Purge()
+	if l.Len() != 0 || len(l.Keys()) != 0 {
+		t.Fatalf("cache should be empty")
+	}
+	l.Add(6, 6)
+	if got, want := l.Keys(), []int{6}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys: got %v, want %v", got, want)
+	}
+}
+
+func TestLFUResize(t *testing.T) {
+	l, _ := NewLFU[int, int](4, nil)
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+		for j := 0; j < i; j++ {
+			l.Get(i)
+		}
+	}
+	if evicted := l.Resize(2); evicted != 2 {
+		t.Fatalf("2 elements should have been evicted: %v", evicted)
+	}
+	if got, want := l.Keys(), []int{2, 3}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,232 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 19:36:58
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-24 10:38:06
+ */
+
+package lru
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultNegativeCacheSize bounds the number of cached load errors.
+const DefaultNegativeCacheSize = 1024
+
+// ErrLoaderPanicked is wrapped by the error of a load whose loader panicked.
+var ErrLoaderPanicked = errors.New("loader panicked")
+
+// LoaderFunc loads the value of a key missing from the cache.
+type LoaderFunc[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+// call is an in flight load shared by every caller asking for the same key.
+type call[V any] struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+	value   V
+	err     error
+}
+
+// LoadingCache wraps a cache so that misses are filled by a loader.
+// Concurrent loads of the same key are coalesced into a single call, and load
+// errors can be cached for a negative ttl. It is safe for concurrent use, the
+// wrapped cache must not be used directly afterwards.
+type LoadingCache[K comparable, V any] struct {
+	mu    sync.Mutex
+	cache Policy[K, V]
+	calls map[K]*call[V]
+	errs  *ExpirableCache[K, error]
+
+	metrics *Metrics
+}
+
+var _ Policy[int, int] = (*LoadingCache[int, int])(nil)
+
+// NewLoading wraps cache, load errors are cached for negativeTTL if it is
+// positive.
+func NewLoading[K comparable, V any](cache Policy[K, V], negativeTTL time.Duration) (*LoadingCache[K, V], error) {
+	if cache == nil {
+		return nil, errors.New("must provide a cache")
+	}
+	c := &LoadingCache[K, V]{
+		cache: cache,
+		calls: make(map[K]*call[V]),
+	}
+	if negativeTTL > 0 {
+		errs, err := NewExpirable[K, error](DefaultNegativeCacheSize, negativeTTL, nil)
+		if err != nil {
+			return nil, err
+		}
+		c.errs = errs
+	}
+	return c, nil
+}
+
+// SetMetrics records the latency and the errors of the loads into m. Hits
+// and misses are recorded by the wrapped cache, see InstrumentedCache.
+func (c *LoadingCache[K, V]) SetMetrics(m *Metrics) {
+	c.mu.Lock()
+	c.metrics = m
+	c.mu.Unlock()
+}
+
+// GetOrLoad returns the cached value of key, or loads it. Callers missing the
+// same key wait for one shared load. A caller whose ctx is done stops waiting
+// with ctx.Err(), and the load itself is cancelled once no caller is left.
+func (c *LoadingCache[K, V]) GetOrLoad(ctx context.Context, key K, loader LoaderFunc[K, V]) (value V, err error) {
+	c.mu.Lock()
+	if value, ok := c.cache.Get(key); ok {
+		c.mu.Unlock()
+		return value, nil
+	}
+	if c.errs != nil {
+		if err, ok := c.errs.Get(key); ok {
+			c.mu.Unlock()
+			return value, err
+		}
+	}
+	cl, ok := c.calls[key]
+	if !ok {
+		loadCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		cl = &call[V]{done: make(chan struct{}), cancel: cancel}
+		c.calls[key] = cl
+		go c.load(loadCtx, key, cl, loader)
+	}
+	cl.waiters++
+	c.mu.Unlock()
+
+	select {
+	case <-cl.done:
+		return cl.value, cl.err
+	case <-ctx.Done():
+		c.mu.Lock()
+		cl.waiters--
+		if cl.waiters == 0 {
+			// nobody is waiting anymore, later callers start a new load
+			if c.calls[key] == cl {
+				delete(c.calls, key)
+			}
+			cl.cancel()
+		}
+		c.mu.Unlock()
+		return value, ctx.Err()
+	}
+}
+
+// callLoader runs loader, turning a panic into an error since it runs on a
+// goroutine no caller could recover it from.
+func callLoader[K comparable, V any](ctx context.Context, key K, loader LoaderFunc[K, V]) (value V, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrLoaderPanicked, r)
+		}
+	}()
+	return loader(ctx, key)
+}
+
+func (c *LoadingCache[K, V]) load(ctx context.Context, key K, cl *call[V], loader LoaderFunc[K, V]) {
+	start := time.Now()
+	value, err := callLoader(ctx, key, loader)
+	elapsed := time.Since(start)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer cl.cancel()
+	if c.metrics != nil {
+		c.metrics.ObserveLoad(elapsed, err)
+	}
+	cl.value, cl.err = value, err
+	close(cl.done)
+	// an Add or Remove during the load forgot the call, its result is older
+	// than the cache then
+	if c.calls[key] != cl {
+		return
+	}
+	delete(c.calls, key)
+
+	switch {
+	case err == nil:
+		c.cache.Add(key, value)
+	case c.errs != nil && ctx.Err() == nil:
+		// errors caused by the cancellation of an abandoned load are not
+		// worth remembering
+		c.errs.Add(key, err)
+	}
+}
+
+// Add sets the value of key, a load of key in flight is not cached.
+func (c *LoadingCache[K, V]) Add(key K, value V) (evicted bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.calls, key)
+	if c.errs != nil {
+		c.errs.Remove(key)
+	}
+	return c.cache.Add(key, value)
+}
+
+func (c *LoadingCache[K, V]) Get(key K) (value V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cache.Get(key)
+}
+
+func (c *LoadingCache[K, V]) Contains(key K) (ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cache.Contains(key)
+}
+
+func (c *LoadingCache[K, V]) Peek(key K) (value V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cache.Peek(key)
+}
+
+// Remove removes the key and any cached load error for it, a load of key in
+// flight is not cached.
+func (c *LoadingCache[K, V]) Remove(key K) (present bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.calls, key)
+	if c.errs != nil {
+		c.errs.Remove(key)
+	}
+	return c.cache.Remove(key)
+}
+
+func (c *LoadingCache[K, V]) Keys() []K {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cache.Keys()
+}
+
+func (c *LoadingCache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cache.Len()
+}
+
+// Purge empties the cache and the cached load errors, the loads in flight
+// are not cached.
+func (c *LoadingCache[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.calls)
+	if c.errs != nil {
+		c.errs.Purge()
+	}
+	c.cache.Purge()
+}
+
+func (c *LoadingCache[K, V]) Resize(size int) (evicted int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cache.Resize(size)
+}
@@ -0,0 +1,185 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 20:02:13
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-24 10:38:06
+ */
+
+package lru
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestLoading(t *testing.T, negativeTTL time.Duration) *LoadingCache[int, int] {
+	t.Helper()
+	lru, _ := New[int, int](16, nil)
+	c, err := NewLoading[int, int](lru, negativeTTL)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return c
+}
+
+func TestLoadingCoalesce(t *testing.T) {
+	c := newTestLoading(t, 0)
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context, key int) (int, error) {
+		calls.Add(1)
+		<-release
+		return key * 2, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := c.GetOrLoad(context.Background(), 21, loader); err != nil || v != 42 {
+				t.Errorf("bad load: %v, %v", v, err)
+			}
+		}()
+	}
+	// wait until every caller joined the load
+	for {
+		c.mu.Lock()
+		cl := c.calls[21]
+		joined := cl != nil && cl.waiters == 10
+		c.mu.Unlock()
+		if joined {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("loader should be called once: %v", n)
+	}
+	if v, ok := c.Get(21); !ok || v != 42 {
+		t.Fatalf("loaded value should be cached: %v", v)
+	}
+	if v, err := c.GetOrLoad(context.Background(), 21, loader); err != nil || v != 42 || calls.Load() != 1 {
+		t.Fatalf("cached value should not be loaded again")
+	}
+}
+
+func TestLoadingNegativeCache(t *testing.T) {
+	errNotFound := errors.New("not found")
+	var calls atomic.Int32
+	loader := func(ctx context.Context, key int) (int, error) {
+		calls.Add(1)
+		return 0, errNotFound
+	}
+
+	c := newTestLoading(t, time.Minute)
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetOrLoad(context.Background(), 1, loader); err != errNotFound {
+			t.Fatalf("bad error: %v", err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("error should be cached: %v calls", n)
+	}
+	c.Remove(1)
+	c.GetOrLoad(context.Background(), 1, loader)
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("Remove should drop the cached error: %v calls", n)
+	}
+
+	// without a negative ttl every miss loads again
+	c = newTestLoading(t, 0)
+	c.GetOrLoad(context.Background(), 1, loader)
+	c.GetOrLoad(context.Background(), 1, loader)
+	if n := calls.Load(); n != 4 {
+		t.Fatalf("error should not be cached: %v calls", n)
+	}
+}
+
+func TestLoadingCancel(t *testing.T) {
+	c := newTestLoading(t, time.Minute)
+	cancelled := make(chan struct{})
+	loader := func(ctx context.Context, key int) (int, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return 0, ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := c.GetOrLoad(ctx, 1, loader); err != context.Canceled {
+		t.Fatalf("bad error: %v", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatalf("the abandoned load should be cancelled")
+	}
+
+	// the cancellation is not cached as a load error
+	v, err := c.GetOrLoad(context.Background(), 1, func(ctx context.Context, key int) (int, error) {
+		return 7, nil
+	})
+	if err != nil || v != 7 {
+		t.Fatalf("bad load after cancel: %v, %v", v, err)
+	}
+}
+
+func TestLoadingWriteDuringLoad(t *testing.T) {
+	for _, write := range []func(c *LoadingCache[int, int]){
+		func(c *LoadingCache[int, int]) { c.Add(1, 222) },
+		func(c *LoadingCache[int, int]) { c.Remove(1) },
+		func(c *LoadingCache[int, int]) { c.Purge() },
+	} {
+		c := newTestLoading(t, 0)
+		started, release := make(chan struct{}), make(chan struct{})
+		loader := func(ctx context.Context, key int) (int, error) {
+			close(started)
+			<-release
+			return 111, nil
+		}
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			// the callers of the load still get its value
+			if v, err := c.GetOrLoad(context.Background(), 1, loader); err != nil || v != 111 {
+				t.Errorf("bad load: %v, %v", v, err)
+			}
+		}()
+		<-started
+		write(c)
+		want, wantOK := c.Peek(1)
+		close(release)
+		<-done
+
+		if v, ok := c.Peek(1); v != want || ok != wantOK {
+			t.Fatalf("the load should not overwrite the write: got %v, %v, want %v, %v", v, ok, want, wantOK)
+		}
+	}
+}
+
+func TestLoadingPanic(t *testing.T) {
+	c := newTestLoading(t, time.Minute)
+	_, err := c.GetOrLoad(context.Background(), 1, func(ctx context.Context, key int) (int, error) {
+		panic("boom")
+	})
+	if !errors.Is(err, ErrLoaderPanicked) {
+		t.Fatalf("bad error: %v", err)
+	}
+	if _, err := c.GetOrLoad(context.Background(), 1, nil); !errors.Is(err, ErrLoaderPanicked) {
+		t.Fatalf("the panic should be cached as a load error: %v", err)
+	}
+	if c.Contains(1) {
+		t.Fatalf("nothing should be cached")
+	}
+}
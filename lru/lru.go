@@ -2,7 +2,7 @@
  * @Author: zengzh
  * @Date: 2023-07-10 08:59:15
  * @Last Modified by: zengzh
- * @Last Modified time: 2023-07-10 09:30:35
+ * @Last Modified time: 2026-10-18 10:12:40
  */
 
 package lru
@@ -13,11 +13,18 @@ import (
 
 type entry[K comparable, V any] struct {
 	next, prev *entry[K, V]
-	list       *lrulist[K, V]
+	list       *lruList[K, V]
 	key        K
 	value      V
 }
 
+func (e *entry[K, V]) nextEntry() *entry[K, V] {
+	if n := e.next; e.list != nil && n != &e.list.root {
+		return n
+	}
+	return nil
+}
+
 func (e *entry[K, V]) prevEntry() *entry[K, V] {
 	if p := e.prev; e.list != nil && p != &e.list.root {
 		return p
@@ -45,6 +52,13 @@ func (l *lruList[K, V]) length() int {
 	return l.len
 }
 
+func (l *lruList[K, V]) front() *entry[K, V] {
+	if l.len == 0 {
+		return nil
+	}
+	return l.root.next
+}
+
 func (l *lruList[K, V]) back() *entry[K, V] {
 	if l.len == 0 {
 		return nil
@@ -58,7 +72,7 @@ func (l *lruList[K, V]) lazyInit() {
 	}
 }
 
-func (l *lrulist[K, V]) insert(e, at *entry[K, V]) *entry[K, V] {
+func (l *lruList[K, V]) insert(e, at *entry[K, V]) *entry[K, V] {
 	e.prev = at
 	e.next = at.next
 	e.prev.next = e
@@ -72,4 +86,234 @@ func (l *lruList[K, V]) insertValue(k K, v V, at *entry[K, V]) *entry[K, V] {
 	return l.insert(&entry[K, V]{value: v, key: k}, at)
 }
 
+func (l *lruList[K, V]) remove(e *entry[K, V]) V {
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	e.next = nil
+	e.prev = nil
+	e.list = nil
+	l.len--
+	return e.value
+}
+
+func (l *lruList[K, V]) move(e, at *entry[K, V]) {
+	if e == at {
+		return
+	}
+	e.prev.next = e.next
+	e.next.prev = e.prev
+
+	e.prev = at
+	e.next = at.next
+	e.prev.next = e
+	e.next.prev = e
+}
+
+func (l *lruList[K, V]) pushFront(k K, v V) *entry[K, V] {
+	l.lazyInit()
+	return l.insertValue(k, v, &l.root)
+}
+
+func (l *lruList[K, V]) pushBack(k K, v V) *entry[K, V] {
+	l.lazyInit()
+	return l.insertValue(k, v, l.root.prev)
+}
+
+func (l *lruList[K, V]) moveToFront(e *entry[K, V]) {
+	if e.list != l || l.root.next == e {
+		return
+	}
+	l.move(e, &l.root)
+}
+
+func (l *lruList[K, V]) moveToBack(e *entry[K, V]) {
+	if e.list != l || l.root.prev == e {
+		return
+	}
+	l.move(e, l.root.prev)
+}
+
 type EvictCallback[K comparable, V any] func(key K, value V)
+
+// EvictReason tells why an entry left the cache.
+type EvictReason int
+
+const (
+	EvictCapacity EvictReason = iota
+	EvictExpired
+	EvictRemoved
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictCapacity:
+		return "capacity"
+	case EvictExpired:
+		return "expired"
+	case EvictRemoved:
+		return "removed"
+	}
+	return "unknown"
+}
+
+// EvictReasonCallback is an EvictCallback that is also told the reason.
+type EvictReasonCallback[K comparable, V any] func(key K, value V, reason EvictReason)
+
+// Policy is the API shared by the caches of this package, so callers can
+// switch eviction policies by constructor only.
+type Policy[K comparable, V any] interface {
+	Add(key K, value V) (evicted bool)
+	Get(key K) (value V, ok bool)
+	Contains(key K) (ok bool)
+	Peek(key K) (value V, ok bool)
+	Remove(key K) (present bool)
+	Keys() []K
+	Len() int
+	Purge()
+	Resize(size int) (evicted int)
+}
+
+var _ Policy[int, int] = (*Cache[int, int])(nil)
+
+// Cache is a fixed size LRU cache. It is not safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	size      int
+	evictList *lruList[K, V]
+	items     map[K]*entry[K, V]
+	onEvict   EvictCallback[K, V]
+}
+
+func New[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*Cache[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	return &Cache[K, V]{
+		size:      size,
+		evictList: newList[K, V](),
+		items:     make(map[K]*entry[K, V]),
+		onEvict:   onEvict,
+	}, nil
+}
+
+func (c *Cache[K, V]) Purge() {
+	for k, v := range c.items {
+		if c.onEvict != nil {
+			c.onEvict(k, v.value)
+		}
+		delete(c.items, k)
+	}
+	c.evictList.init()
+}
+
+// Add adds a value to the cache, returns true if an eviction occurred.
+func (c *Cache[K, V]) Add(key K, value V) (evicted bool) {
+	if ent, ok := c.items[key]; ok {
+		c.evictList.moveToFront(ent)
+		ent.value = value
+		return false
+	}
+
+	ent := c.evictList.pushFront(key, value)
+	c.items[key] = ent
+
+	evict := c.evictList.length() > c.size
+	if evict {
+		c.removeOldest()
+	}
+	return evict
+}
+
+func (c *Cache[K, V]) Get(key K) (value V, ok bool) {
+	if ent, ok := c.items[key]; ok {
+		c.evictList.moveToFront(ent)
+		return ent.value, true
+	}
+	return
+}
+
+// Contains checks if a key is in the cache, without updating the recent-ness.
+func (c *Cache[K, V]) Contains(key K) (ok bool) {
+	_, ok = c.items[key]
+	return ok
+}
+
+// Peek returns the key value without updating the recent-ness.
+func (c *Cache[K, V]) Peek(key K) (value V, ok bool) {
+	var ent *entry[K, V]
+	if ent, ok = c.items[key]; ok {
+		return ent.value, true
+	}
+	return
+}
+
+func (c *Cache[K, V]) Remove(key K) (present bool) {
+	if ent, ok := c.items[key]; ok {
+		c.removeElement(ent)
+		return true
+	}
+	return false
+}
+
+func (c *Cache[K, V]) RemoveOldest() (key K, value V, ok bool) {
+	if ent := c.evictList.back(); ent != nil {
+		c.removeElement(ent)
+		return ent.key, ent.value, true
+	}
+	return
+}
+
+func (c *Cache[K, V]) GetOldest() (key K, value V, ok bool) {
+	if ent := c.evictList.back(); ent != nil {
+		return ent.key, ent.value, true
+	}
+	return
+}
+
+// Keys returns a slice of the keys in the cache, from oldest to newest.
+func (c *Cache[K, V]) Keys() []K {
+	keys := make([]K, 0, c.evictList.length())
+	for ent := c.evictList.back(); ent != nil; ent = ent.prevEntry() {
+		keys = append(keys, ent.key)
+	}
+	return keys
+}
+
+// Values returns a slice of the values in the cache, from oldest to newest.
+func (c *Cache[K, V]) Values() []V {
+	values := make([]V, 0, c.evictList.length())
+	for ent := c.evictList.back(); ent != nil; ent = ent.prevEntry() {
+		values = append(values, ent.value)
+	}
+	return values
+}
+
+func (c *Cache[K, V]) Len() int {
+	return c.evictList.length()
+}
+
+// Resize changes the cache size, returns the number of evicted entries.
+func (c *Cache[K, V]) Resize(size int) (evicted int) {
+	diff := c.Len() - size
+	if diff < 0 {
+		diff = 0
+	}
+	for i := 0; i < diff; i++ {
+		c.removeOldest()
+	}
+	c.size = size
+	return diff
+}
+
+func (c *Cache[K, V]) removeOldest() {
+	if ent := c.evictList.back(); ent != nil {
+		c.removeElement(ent)
+	}
+}
+
+func (c *Cache[K, V]) removeElement(e *entry[K, V]) {
+	c.evictList.remove(e)
+	delete(c.items, e.key)
+	if c.onEvict != nil {
+		c.onEvict(e.key, e.value)
+	}
+}
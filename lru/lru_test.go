@@ -1 +1,149 @@
-
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 10:05:21
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-18 10:12:40
+ */
+
+package lru
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLRU(t *testing.T) {
+	evictCounter := 0
+	onEvicted := func(k int, v int) {
+		if k != v {
+			t.Fatalf("Evict values not equal (%v!=%v)", k, v)
+		}
+		evictCounter++
+	}
+	l, err := New(128, onEvicted)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 256; i++ {
+		l.Add(i, i)
+	}
+	if l.Len() != 128 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	if evictCounter != 128 {
+		t.Fatalf("bad evict count: %v", evictCounter)
+	}
+
+	for i, k := range l.Keys() {
+		if v, ok := l.Get(k); !ok || v != k || v != i+128 {
+			t.Fatalf("bad key: %v", k)
+		}
+	}
+	for i := 0; i < 128; i++ {
+		if _, ok := l.Get(i); ok {
+			t.Fatalf("should be evicted")
+		}
+	}
+	for i := 128; i < 256; i++ {
+		if _, ok := l.Get(i); !ok {
+			t.Fatalf("should not be evicted")
+		}
+	}
+	for i := 128; i < 192; i++ {
+		if !l.Remove(i) {
+			t.Fatalf("should be contained")
+		}
+		if l.Remove(i) {
+			t.Fatalf("should not be contained")
+		}
+		if _, ok := l.Get(i); ok {
+			t.Fatalf("should be deleted")
+		}
+	}
+
+	l.Get(192) // expect 192 to be last key in l.Keys()
+	for i, k := range l.Keys() {
+		if (i < 63 && k != i+193) || (i == 63 && k != 192) {
+			t.Fatalf("out of order key: %v", k)
+		}
+	}
+
+	l.Purge()
+	if l.Len() != 0 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	if _, ok := l.Get(200); ok {
+		t.Fatalf("should contain nothing")
+	}
+}
+
+func TestLRUInvalidSize(t *testing.T) {
+	if _, err := New[int, int](0, nil); err == nil {
+		t.Fatalf("expected error for zero size")
+	}
+}
+
+func TestLRUPeekContains(t *testing.T) {
+	l, _ := New[int, int](2, nil)
+	l.Add(1, 1)
+	l.Add(2, 2)
+	if !l.Contains(1) {
+		t.Fatalf("1 should be contained")
+	}
+	if v, ok := l.Peek(1); !ok || v != 1 {
+		t.Fatalf("1 should be set to 1: %v, %v", v, ok)
+	}
+	// neither Contains nor Peek refreshes 1, so it is evicted
+	l.Add(3, 3)
+	if l.Contains(1) {
+		t.Fatalf("Contains should not have updated recent-ness of 1")
+	}
+}
+
+func TestLRURemoveOldest(t *testing.T) {
+	l, _ := New[int, int](2, nil)
+	if _, _, ok := l.RemoveOldest(); ok {
+		t.Fatalf("empty cache should have nothing to remove")
+	}
+	l.Add(1, 1)
+	l.Add(2, 2)
+	if k, _, ok := l.GetOldest(); !ok || k != 1 {
+		t.Fatalf("oldest should be 1: %v", k)
+	}
+	if k, _, ok := l.RemoveOldest(); !ok || k != 1 {
+		t.Fatalf("removed oldest should be 1: %v", k)
+	}
+	if l.Len() != 1 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+}
+
+func TestLRUResize(t *testing.T) {
+	onEvictCounter := 0
+	l, _ := New(2, func(k int, v int) { onEvictCounter++ })
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	if evicted := l.Resize(1); evicted != 1 {
+		t.Fatalf("1 element should have been evicted: %v", evicted)
+	}
+	if onEvictCounter != 1 {
+		t.Fatalf("onEvicted should have been called 1 time: %v", onEvictCounter)
+	}
+	l.Add(3, 3)
+	if l.Contains(1) {
+		t.Fatalf("element 1 should have been evicted")
+	}
+
+	if evicted := l.Resize(2); evicted != 0 {
+		t.Fatalf("0 elements should have been evicted: %v", evicted)
+	}
+	l.Add(4, 4)
+	if !l.Contains(3) || !l.Contains(4) {
+		t.Fatalf("cache should have contained 2 elements")
+	}
+	if got, want := l.Values(), []int{3, 4}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("values: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,322 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 21:47:02
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-25 12:06:31
+ */
+
+package lru
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the load latency
+// histogram.
+var DefaultLatencyBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var evictReasons = []EvictReason{EvictCapacity, EvictExpired, EvictRemoved}
+
+// Metrics counts the activity of a cache. All methods are safe for
+// concurrent use, so one Metrics can be scraped while the cache is in use.
+type Metrics struct {
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions [3]atomic.Uint64
+	size      atomic.Int64
+	cost      atomic.Int64
+
+	loads       atomic.Uint64
+	loadErrors  atomic.Uint64
+	buckets     []float64
+	loadCounts  []atomic.Uint64
+	loadSumNano atomic.Int64
+}
+
+func NewMetrics() *Metrics {
+	return NewMetricsWithBuckets(DefaultLatencyBuckets)
+}
+
+// NewMetricsWithBuckets uses the given ascending histogram bounds, in seconds.
+func NewMetricsWithBuckets(buckets []float64) *Metrics {
+	return &Metrics{
+		buckets:    append([]float64(nil), buckets...),
+		loadCounts: make([]atomic.Uint64, len(buckets)),
+	}
+}
+
+func (m *Metrics) Hit() {
+	m.hits.Add(1)
+}
+
+func (m *Metrics) Miss() {
+	m.misses.Add(1)
+}
+
+func (m *Metrics) Evicted(reason EvictReason) {
+	if int(reason) >= 0 && int(reason) < len(m.evictions) {
+		m.evictions[reason].Add(1)
+	}
+}
+
+// ObserveLoad records the latency of a load and whether it failed.
+func (m *Metrics) ObserveLoad(d time.Duration, err error) {
+	m.loads.Add(1)
+	if err != nil {
+		m.loadErrors.Add(1)
+	}
+	m.loadSumNano.Add(int64(d))
+	seconds := d.Seconds()
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			m.loadCounts[i].Add(1)
+			break
+		}
+	}
+}
+
+// Stats is a point in time snapshot of Metrics.
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions map[EvictReason]uint64
+	Size      int64
+	Cost      int64
+
+	Loads      uint64
+	LoadErrors uint64
+	// LoadBuckets[i] counts the loads not slower than LoadBounds[i] seconds,
+	// cumulatively like a Prometheus histogram.
+	LoadBounds  []float64
+	LoadBuckets []uint64
+	LoadSum     time.Duration
+}
+
+// HitRatio returns hits / (hits + misses), 0 without any lookup.
+func (s Stats) HitRatio() float64 {
+	if total := s.Hits + s.Misses; total > 0 {
+		return float64(s.Hits) / float64(total)
+	}
+	return 0
+}
+
+func (m *Metrics) Stats() Stats {
+	s := Stats{
+		Hits:        m.hits.Load(),
+		Misses:      m.misses.Load(),
+		Evictions:   make(map[EvictReason]uint64, len(evictReasons)),
+		Size:        m.size.Load(),
+		Cost:        m.cost.Load(),
+		Loads:       m.loads.Load(),
+		LoadErrors:  m.loadErrors.Load(),
+		LoadBounds:  m.buckets,
+		LoadBuckets: make([]uint64, len(m.buckets)),
+		LoadSum:     time.Duration(m.loadSumNano.Load()),
+	}
+	for _, r := range evictReasons {
+		s.Evictions[r] = m.evictions[r].Load()
+	}
+	var cumulative uint64
+	for i := range m.loadCounts {
+		cumulative += m.loadCounts[i].Load()
+		s.LoadBuckets[i] = cumulative
+	}
+	return s
+}
+
+// WritePrometheus writes the metrics in the Prometheus text exposition
+// format, every metric name prefixed by name.
+func (m *Metrics) WritePrometheus(w io.Writer, name string) error {
+	s := m.Stats()
+	bw := bufio.NewWriter(w)
+	metric := func(suffix, typ, help string) string {
+		full := name + "_" + suffix
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", full, help, full, typ)
+		return full
+	}
+
+	fmt.Fprintf(bw, "%s %d\n", metric("hits_total", "counter", "Number of cache hits."), s.Hits)
+	fmt.Fprintf(bw, "%s %d\n", metric("misses_total", "counter", "Number of cache misses."), s.Misses)
+	full := metric("evictions_total", "counter", "Number of evicted entries by reason.")
+	for _, r := range evictReasons {
+		fmt.Fprintf(bw, "%s{reason=%q} %d\n", full, r.String(), s.Evictions[r])
+	}
+	fmt.Fprintf(bw, "%s %d\n", metric("size", "gauge", "Number of cached entries."), s.Size)
+	fmt.Fprintf(bw, "%s %d\n", metric("cost", "gauge", "Total cost of the cached entries."), s.Cost)
+	fmt.Fprintf(bw, "%s %d\n", metric("load_errors_total", "counter", "Number of failed loads."), s.LoadErrors)
+
+	full = metric("load_duration_seconds", "histogram", "Latency of loads.")
+	for i, bound := range s.LoadBounds {
+		fmt.Fprintf(bw, "%s_bucket{le=%q} %d\n", full, strconv.FormatFloat(bound, 'g', -1, 64), s.LoadBuckets[i])
+	}
+	fmt.Fprintf(bw, "%s_bucket{le=\"+Inf\"} %d\n", full, s.Loads)
+	fmt.Fprintf(bw, "%s_sum %s\n", full, strconv.FormatFloat(s.LoadSum.Seconds(), 'g', -1, 64))
+	fmt.Fprintf(bw, "%s_count %d\n", full, s.Loads)
+	return bw.Flush()
+}
+
+// Handler serves the metrics in the Prometheus text exposition format.
+func (m *Metrics) Handler(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.WritePrometheus(w, name)
+	})
+}
+
+// Hooks are the eviction callbacks of an InstrumentedCache, to be passed to
+// the constructor of the wrapped cache.
+type Hooks[K comparable, V any] struct {
+	OnEvict       EvictCallback[K, V]
+	OnEvictReason EvictReasonCallback[K, V]
+}
+
+// InstrumentedCache wraps a cache and records its hits, misses, evictions
+// and size into Metrics. It serializes the calls to the wrapped cache, so it
+// is safe for concurrent use.
+type InstrumentedCache[K comparable, V any] struct {
+	mu       sync.Mutex
+	cache    Policy[K, V]
+	metrics  *Metrics
+	onEvict  EvictReasonCallback[K, V]
+	removing bool
+}
+
+var _ Policy[int, int] = (*InstrumentedCache[int, int])(nil)
+
+// NewInstrumented builds the wrapped cache with build, which must hand one of
+// the hooks to the cache constructor, e.g.
+//
+//	lru.NewInstrumented(m, func(h lru.Hooks[string, []byte]) (lru.Policy[string, []byte], error) {
+//		return lru.New(128, h.OnEvict)
+//	}, nil)
+//
+// onEvict, if not nil, is called after an eviction has been recorded.
+func NewInstrumented[K comparable, V any](m *Metrics, build func(h Hooks[K, V]) (Policy[K, V], error), onEvict EvictReasonCallback[K, V]) (*InstrumentedCache[K, V], error) {
+	if m == nil {
+		return nil, errors.New("must provide metrics")
+	}
+	c := &InstrumentedCache[K, V]{metrics: m, onEvict: onEvict}
+	cache, err := build(Hooks[K, V]{OnEvict: c.evicted, OnEvictReason: c.evictedReason})
+	if err != nil {
+		return nil, err
+	}
+	if cache == nil {
+		return nil, errors.New("build must return a cache")
+	}
+	c.cache = cache
+	return c, nil
+}
+
+// evicted is the hook for caches without a reason, entries leaving through
+// Remove or Purge are told apart by the removing flag.
+func (c *InstrumentedCache[K, V]) evicted(key K, value V) {
+	reason := EvictCapacity
+	if c.removing {
+		reason = EvictRemoved
+	}
+	c.evictedReason(key, value, reason)
+}
+
+// evictedReason also runs on the janitor of an ExpirableCache, outside of
+// any call to the wrapper, so it keeps the size gauge in step by itself. The
+// wrapped cache holds its lock here and cannot be asked for its length.
+func (c *InstrumentedCache[K, V]) evictedReason(key K, value V, reason EvictReason) {
+	c.metrics.Evicted(reason)
+	c.metrics.size.Add(-1)
+	if c.onEvict != nil {
+		c.onEvict(key, value, reason)
+	}
+}
+
+func (c *InstrumentedCache[K, V]) updateSize() {
+	c.metrics.size.Store(int64(c.cache.Len()))
+	if w, ok := c.cache.(interface{ Cost() int64 }); ok {
+		c.metrics.cost.Store(w.Cost())
+	}
+}
+
+// Metrics returns the metrics the cache records into.
+func (c *InstrumentedCache[K, V]) Metrics() *Metrics {
+	return c.metrics
+}
+
+func (c *InstrumentedCache[K, V]) Add(key K, value V) (evicted bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	evicted = c.cache.Add(key, value)
+	c.updateSize()
+	return evicted
+}
+
+func (c *InstrumentedCache[K, V]) Get(key K) (value V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok = c.cache.Get(key)
+	if ok {
+		c.metrics.Hit()
+	} else {
+		c.metrics.Miss()
+		// a miss may have dropped an expired entry
+		c.updateSize()
+	}
+	return value, ok
+}
+
+func (c *InstrumentedCache[K, V]) Contains(key K) (ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cache.Contains(key)
+}
+
+func (c *InstrumentedCache[K, V]) Peek(key K) (value V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cache.Peek(key)
+}
+
+func (c *InstrumentedCache[K, V]) Remove(key K) (present bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removing = true
+	present = c.cache.Remove(key)
+	c.removing = false
+	c.updateSize()
+	return present
+}
+
+func (c *InstrumentedCache[K, V]) Keys() []K {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cache.Keys()
+}
+
+func (c *InstrumentedCache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cache.Len()
+}
+
+func (c *InstrumentedCache[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removing = true
+	c.cache.Purge()
+	c.removing = false
+	c.updateSize()
+}
+
+func (c *InstrumentedCache[K, V]) Resize(size int) (evicted int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	evicted = c.cache.Resize(size)
+	c.updateSize()
+	return evicted
+}
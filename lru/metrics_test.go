@@ -0,0 +1,164 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 22:38:15
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-25 12:06:31
+ */
+
+package lru
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInstrumented(t *testing.T) {
+	m := NewMetrics()
+	var reasons []EvictReason
+	c, err := NewInstrumented(m, func(h Hooks[int, int]) (Policy[int, int], error) {
+		return New(2, h.OnEvict)
+	}, func(k int, v int, r EvictReason) {
+		reasons = append(reasons, r)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.Add(1, 1)
+	c.Add(2, 2)
+	c.Add(3, 3)
+	c.Get(2)
+	c.Get(1)
+	c.Remove(3)
+
+	s := c.Metrics().Stats()
+	if s.Hits != 1 || s.Misses != 1 || s.Size != 1 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+	if s.Evictions[EvictCapacity] != 1 || s.Evictions[EvictRemoved] != 1 {
+		t.Fatalf("bad evictions: %v", s.Evictions)
+	}
+	if len(reasons) != 2 || reasons[0] != EvictCapacity || reasons[1] != EvictRemoved {
+		t.Fatalf("bad reasons: %v", reasons)
+	}
+	if r := s.HitRatio(); r != 0.5 {
+		t.Fatalf("bad hit ratio: %v", r)
+	}
+}
+
+func TestInstrumentedExpirableAndWeighted(t *testing.T) {
+	m := NewMetrics()
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	c, _ := NewInstrumented(m, func(h Hooks[int, int]) (Policy[int, int], error) {
+		e, err := NewExpirable(4, time.Second, h.OnEvictReason)
+		if err == nil {
+			e.now = clock.now
+		}
+		return e, err
+	}, nil)
+	c.Add(1, 1)
+	clock.advance(2 * time.Second)
+	c.Get(1)
+	if s := m.Stats(); s.Evictions[EvictExpired] != 1 || s.Size != 0 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+
+	m = NewMetrics()
+	w, _ := NewInstrumented(m, func(h Hooks[string, []byte]) (Policy[string, []byte], error) {
+		return NewWeighted(10, byteLen, h.OnEvict)
+	}, nil)
+	w.Add("a", make([]byte, 4))
+	w.Add("b", make([]byte, 3))
+	if s := m.Stats(); s.Cost != 7 || s.Size != 2 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+}
+
+func TestInstrumentedJanitor(t *testing.T) {
+	m := NewMetrics()
+	var e *ExpirableCache[int, int]
+	c, _ := NewInstrumented(m, func(h Hooks[int, int]) (Policy[int, int], error) {
+		var err error
+		e, err = NewExpirableWithJanitor(4, 20*time.Millisecond, h.OnEvictReason)
+		return e, err
+	}, nil)
+	defer e.Close()
+	for i := 0; i < 3; i++ {
+		c.Add(i, i)
+	}
+	if s := m.Stats(); s.Size != 3 {
+		t.Fatalf("bad size: %v", s.Size)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for m.Stats().Evictions[EvictExpired] < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("entries should expire: %+v", m.Stats())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if s := m.Stats(); s.Size != 0 {
+		t.Fatalf("expirations should update the size: %v", s.Size)
+	}
+}
+
+func TestMetricsLoad(t *testing.T) {
+	m := NewMetricsWithBuckets([]float64{0.01, 1})
+	m.ObserveLoad(5*time.Millisecond, nil)
+	m.ObserveLoad(500*time.Millisecond, errors.New("boom"))
+	m.ObserveLoad(5*time.Second, nil)
+	s := m.Stats()
+	if s.Loads != 3 || s.LoadErrors != 1 {
+		t.Fatalf("bad loads: %+v", s)
+	}
+	if s.LoadBuckets[0] != 1 || s.LoadBuckets[1] != 2 {
+		t.Fatalf("buckets should be cumulative: %v", s.LoadBuckets)
+	}
+
+	// the loading cache observes its loads
+	lru, _ := New[int, int](4, nil)
+	l, _ := NewLoading[int, int](lru, 0)
+	m = NewMetrics()
+	l.SetMetrics(m)
+	l.GetOrLoad(context.Background(), 1, func(ctx context.Context, key int) (int, error) {
+		return key, nil
+	})
+	if s := m.Stats(); s.Loads != 1 {
+		t.Fatalf("load should be observed: %+v", s)
+	}
+}
+
+func TestMetricsPrometheus(t *testing.T) {
+	m := NewMetricsWithBuckets([]float64{0.1})
+	m.Hit()
+	m.Miss()
+	m.Miss()
+	m.Evicted(EvictExpired)
+	m.ObserveLoad(50*time.Millisecond, nil)
+
+	rec := httptest.NewRecorder()
+	m.Handler("users_cache").ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Fatalf("bad content type: %v", ct)
+	}
+	body := rec.Body.String()
+	for _, line := range []string{
+		"# TYPE users_cache_hits_total counter",
+		"users_cache_hits_total 1",
+		"users_cache_misses_total 2",
+		`users_cache_evictions_total{reason="expired"} 1`,
+		`users_cache_evictions_total{reason="capacity"} 0`,
+		"# TYPE users_cache_size gauge",
+		"# TYPE users_cache_load_duration_seconds histogram",
+		`users_cache_load_duration_seconds_bucket{le="0.1"} 1`,
+		`users_cache_load_duration_seconds_bucket{le="+Inf"} 1`,
+		"users_cache_load_duration_seconds_sum 0.05",
+		"users_cache_load_duration_seconds_count 1",
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Fatalf("missing %q in:\n%s", line, body)
+		}
+	}
+}
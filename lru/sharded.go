@@ -0,0 +1,142 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 10:31:07
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-24 09:12:30
+ */
+
+package lru
+
+import (
+	"errors"
+	"hash/maphash"
+	"sync"
+)
+
+const DefaultShardCount = 16
+
+type shard[K comparable, V any] struct {
+	mu        sync.Mutex
+	lru       *Cache[K, V]
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+type ShardStats struct {
+	Len       int
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// ShardedCache is a thread safe LRU cache. Keys are spread over independently
+// locked shards, so the LRU order is only kept per shard.
+type ShardedCache[K comparable, V any] struct {
+	seed   maphash.Seed
+	mask   uint64
+	shards []*shard[K, V]
+}
+
+// NewSharded creates a cache holding up to size entries in total. The shard
+// count is rounded up to a power of two.
+func NewSharded[K comparable, V any](shards, size int, onEvict EvictCallback[K, V]) (*ShardedCache[K, V], error) {
+	if shards <= 0 {
+		return nil, errors.New("must provide a positive shard count")
+	}
+	n := 1
+	for n < shards {
+		n <<= 1
+	}
+	if size < n {
+		return nil, errors.New("size must not be less than the shard count")
+	}
+	c := &ShardedCache[K, V]{
+		seed:   maphash.MakeSeed(),
+		mask:   uint64(n - 1),
+		shards: make([]*shard[K, V], n),
+	}
+	// the first size%n shards take one more entry
+	for i := range c.shards {
+		per := size / n
+		if i < size%n {
+			per++
+		}
+		lru, err := New(per, onEvict)
+		if err != nil {
+			return nil, err
+		}
+		c.shards[i] = &shard[K, V]{lru: lru}
+	}
+	return c, nil
+}
+
+func (c *ShardedCache[K, V]) shardFor(key K) *shard[K, V] {
+	return c.shards[maphash.Comparable(c.seed, key)&c.mask]
+}
+
+func (c *ShardedCache[K, V]) Add(key K, value V) (evicted bool) {
+	s := c.shardFor(key)
+	s.mu.Lock()
+	evicted = s.lru.Add(key, value)
+	if evicted {
+		s.evictions++
+	}
+	s.mu.Unlock()
+	return evicted
+}
+
+func (c *ShardedCache[K, V]) Get(key K) (value V, ok bool) {
+	s := c.shardFor(key)
+	s.mu.Lock()
+	value, ok = s.lru.Get(key)
+	if ok {
+		s.hits++
+	} else {
+		s.misses++
+	}
+	s.mu.Unlock()
+	return value, ok
+}
+
+func (c *ShardedCache[K, V]) Remove(key K) (present bool) {
+	s := c.shardFor(key)
+	s.mu.Lock()
+	present = s.lru.Remove(key)
+	s.mu.Unlock()
+	return present
+}
+
+func (c *ShardedCache[K, V]) Len() int {
+	length := 0
+	for _, s := range c.shards {
+		s.mu.Lock()
+		length += s.lru.Len()
+		s.mu.Unlock()
+	}
+	return length
+}
+
+func (c *ShardedCache[K, V]) Purge() {
+	for _, s := range c.shards {
+		s.mu.Lock()
+		s.lru.Purge()
+		s.mu.Unlock()
+	}
+}
+
+// ShardStats returns a snapshot of the counters of every shard.
+func (c *ShardedCache[K, V]) ShardStats() []ShardStats {
+	stats := make([]ShardStats, len(c.shards))
+	for i, s := range c.shards {
+		s.mu.Lock()
+		stats[i] = ShardStats{
+			Len:       s.lru.Len(),
+			Hits:      s.hits,
+			Misses:    s.misses,
+			Evictions: s.evictions,
+		}
+		s.mu.Unlock()
+	}
+	return stats
+}
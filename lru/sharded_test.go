@@ -0,0 +1,148 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 10:47:15
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-24 09:12:30
+ */
+
+package lru
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestShardedCache(t *testing.T) {
+	c, err := NewSharded[int, int](3, 256, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(c.shards) != 4 {
+		t.Fatalf("shard count should be rounded to 4: %v", len(c.shards))
+	}
+	for i := 0; i < 100; i++ {
+		c.Add(i, i)
+	}
+	if c.Len() != 100 {
+		t.Fatalf("bad len: %v", c.Len())
+	}
+	for i := 0; i < 100; i++ {
+		if v, ok := c.Get(i); !ok || v != i {
+			t.Fatalf("bad value for %v: %v, %v", i, v, ok)
+		}
+	}
+	if _, ok := c.Get(1000); ok {
+		t.Fatalf("1000 should not be contained")
+	}
+	if !c.Remove(1) || c.Remove(1) {
+		t.Fatalf("1 should be removed exactly once")
+	}
+
+	var hits, misses uint64
+	length := 0
+	for _, s := range c.ShardStats() {
+		hits += s.Hits
+		misses += s.Misses
+		length += s.Len
+	}
+	if hits != 100 || misses != 1 || length != 99 {
+		t.Fatalf("bad stats: hits %v, misses %v, len %v", hits, misses, length)
+	}
+
+	c.Purge()
+	if c.Len() != 0 {
+		t.Fatalf("bad len: %v", c.Len())
+	}
+}
+
+func TestShardedCacheConcurrent(t *testing.T) {
+	c, _ := NewSharded[int, int](8, 128, nil)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				k := rand.Intn(512)
+				c.Add(k, k)
+				if v, ok := c.Get(k); ok && v != k {
+					t.Errorf("bad value for %v: %v", k, v)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if c.Len() > 128 {
+		t.Fatalf("bad len: %v", c.Len())
+	}
+}
+
+func TestShardedCacheSize(t *testing.T) {
+	for _, tc := range []struct{ shards, size int }{{3, 6}, {4, 5}, {16, 1000}, {16, 16}} {
+		c, err := NewSharded[int, int](tc.shards, tc.size, nil)
+		if err != nil {
+			t.Fatalf("%v shards, size %v: %v", tc.shards, tc.size, err)
+		}
+		for i := 0; i < 100*tc.size; i++ {
+			c.Add(i, i)
+		}
+		if c.Len() != tc.size {
+			t.Fatalf("%v shards, size %v: bad len %v", tc.shards, tc.size, c.Len())
+		}
+	}
+	// 3 shards are rounded up to 4
+	if _, err := NewSharded[int, int](3, 3, nil); err == nil {
+		t.Fatalf("size 3 should be less than the shard count")
+	}
+}
+
+// lockedCache guards a single Cache with one mutex, the baseline for the
+// sharded benchmarks.
+type lockedCache[K comparable, V any] struct {
+	mu  sync.Mutex
+	lru *Cache[K, V]
+}
+
+func (c *lockedCache[K, V]) Add(key K, value V) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Add(key, value)
+}
+
+func (c *lockedCache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Get(key)
+}
+
+type benchCache interface {
+	Add(key int, value int) bool
+	Get(key int) (int, bool)
+}
+
+func benchmarkParallel(b *testing.B, c benchCache) {
+	for i := 0; i < 8192; i++ {
+		c.Add(i, i)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		for pb.Next() {
+			k := r.Intn(16384)
+			if _, ok := c.Get(k); !ok {
+				c.Add(k, k)
+			}
+		}
+	})
+}
+
+func BenchmarkShardedCacheParallel(b *testing.B) {
+	c, _ := NewSharded[int, int](DefaultShardCount, 8192, nil)
+	benchmarkParallel(b, c)
+}
+
+func BenchmarkLockedCacheParallel(b *testing.B) {
+	lru, _ := New[int, int](8192, nil)
+	benchmarkParallel(b, &lockedCache[int, int]{lru: lru})
+}
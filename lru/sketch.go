@@ -0,0 +1,152 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 16:48:19
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-18 17:36:02
+ */
+
+package lru
+
+const (
+	cmDepth          = 4
+	cmMaxCount       = 15
+	doorkeeperHash   = 3
+	sampleMultiplier = 10
+)
+
+// doorkeeper is a Bloom filter in front of the sketch. A key seen for the
+// first time only sets its bits here, so one hit wonders never reach the
+// counters.
+type doorkeeper struct {
+	bits []uint64
+	mask uint64
+}
+
+func newDoorkeeper(size int) *doorkeeper {
+	n := nextPowerOfTwo(size * 8)
+	return &doorkeeper{
+		bits: make([]uint64, (n+63)/64),
+		mask: uint64(n - 1),
+	}
+}
+
+// add sets the bits of h, returns true if they were all set already.
+func (d *doorkeeper) add(h uint64) bool {
+	found := true
+	for i := 0; i < doorkeeperHash; i++ {
+		bit := indexOf(h, i) & d.mask
+		word, mask := bit/64, uint64(1)<<(bit%64)
+		if d.bits[word]&mask == 0 {
+			found = false
+			d.bits[word] |= mask
+		}
+	}
+	return found
+}
+
+func (d *doorkeeper) contains(h uint64) bool {
+	for i := 0; i < doorkeeperHash; i++ {
+		bit := indexOf(h, i) & d.mask
+		if d.bits[bit/64]&(uint64(1)<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *doorkeeper) reset() {
+	clear(d.bits)
+}
+
+// cmSketch is a count-min sketch estimating how often a key was accessed.
+// Counters saturate at 15 and are halved every sampleMultiplier*size
+// additions, so old popularity fades away.
+type cmSketch struct {
+	rows      [cmDepth][]uint8
+	mask      uint64
+	door      *doorkeeper
+	additions int
+	resetAt   int
+}
+
+func newCMSketch(size int) *cmSketch {
+	width := nextPowerOfTwo(size)
+	s := &cmSketch{
+		mask:    uint64(width - 1),
+		door:    newDoorkeeper(size),
+		resetAt: sampleMultiplier * size,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+func (s *cmSketch) increment(h uint64) {
+	if !s.door.add(h) {
+		s.tick()
+		return
+	}
+	// conservative update, only the smallest counters are increased
+	lowest := s.count(h)
+	if lowest >= cmMaxCount {
+		return
+	}
+	for i := range s.rows {
+		if c := &s.rows[i][indexOf(h, i)&s.mask]; *c == lowest {
+			*c++
+		}
+	}
+	s.tick()
+}
+
+func (s *cmSketch) tick() {
+	s.additions++
+	if s.additions >= s.resetAt {
+		s.reset()
+	}
+}
+
+func (s *cmSketch) count(h uint64) uint8 {
+	lowest := uint8(cmMaxCount)
+	for i := range s.rows {
+		if c := s.rows[i][indexOf(h, i)&s.mask]; c < lowest {
+			lowest = c
+		}
+	}
+	return lowest
+}
+
+// estimate returns the access frequency of h.
+func (s *cmSketch) estimate(h uint64) int {
+	n := int(s.count(h))
+	if s.door.contains(h) {
+		n++
+	}
+	return n
+}
+
+// reset ages the sketch by halving every counter.
+func (s *cmSketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.door.reset()
+	s.additions /= 2
+}
+
+// indexOf derives the i-th hash of h by double hashing.
+func indexOf(h uint64, i int) uint64 {
+	h1, h2 := h&0xffffffff, h>>32
+	return h1 + uint64(i)*(h2|1)
+}
+
+func nextPowerOfTwo(n int) int {
+	p := 16
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
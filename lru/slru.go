@@ -0,0 +1,193 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 15:52:40
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-18 16:21:48
+ */
+
+package lru
+
+import (
+	"errors"
+)
+
+// DefaultSLRUProtectedRatio is the share of the cache used by the protected
+// segment.
+const DefaultSLRUProtectedRatio = 0.8
+
+// SLRUCache is a segmented LRU cache. New keys enter the probationary
+// segment and are promoted to the protected segment on their first hit. When
+// the protected segment overflows its oldest entry is demoted back to
+// probation, and evictions always come from probation first. It is not safe
+// for concurrent use.
+type SLRUCache[K comparable, V any] struct {
+	size          int
+	protectedSize int
+	ratio         float64
+
+	probation, protected *lruList[K, V]
+
+	items   map[K]*entry[K, V]
+	onEvict EvictCallback[K, V]
+}
+
+var _ Policy[int, int] = (*SLRUCache[int, int])(nil)
+
+func NewSLRU[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*SLRUCache[K, V], error) {
+	return NewSLRUParams(size, DefaultSLRUProtectedRatio, onEvict)
+}
+
+// NewSLRUParams creates a segmented LRU cache where protectedRatio sizes the
+// protected segment relative to size.
+func NewSLRUParams[K comparable, V any](size int, protectedRatio float64, onEvict EvictCallback[K, V]) (*SLRUCache[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	if protectedRatio < 0.0 || protectedRatio > 1.0 {
+		return nil, errors.New("invalid protected ratio")
+	}
+	c := &SLRUCache[K, V]{
+		ratio:     protectedRatio,
+		probation: newList[K, V](),
+		protected: newList[K, V](),
+		items:     make(map[K]*entry[K, V]),
+		onEvict:   onEvict,
+	}
+	c.setSize(size)
+	return c, nil
+}
+
+func (c *SLRUCache[K, V]) setSize(size int) {
+	c.size = size
+	c.protectedSize = int(float64(size) * c.ratio)
+}
+
+// promote moves the entry to the front of the protected segment, demoting
+// the oldest protected entry if the segment is full.
+func (c *SLRUCache[K, V]) promote(e *entry[K, V]) {
+	if e.list == c.protected {
+		c.protected.moveToFront(e)
+		return
+	}
+	if c.protectedSize == 0 {
+		c.probation.moveToFront(e)
+		return
+	}
+	c.probation.remove(e)
+	c.protected.insert(e, &c.protected.root)
+	for c.protected.length() > c.protectedSize {
+		old := c.protected.back()
+		c.protected.remove(old)
+		c.probation.insert(old, &c.probation.root)
+	}
+}
+
+func (c *SLRUCache[K, V]) Get(key K) (value V, ok bool) {
+	if ent, ok := c.items[key]; ok {
+		c.promote(ent)
+		return ent.value, true
+	}
+	return
+}
+
+// Add adds a value to the cache, returns true if an eviction occurred.
+// Updating an existing key counts as a hit.
+func (c *SLRUCache[K, V]) Add(key K, value V) (evicted bool) {
+	if ent, ok := c.items[key]; ok {
+		ent.value = value
+		c.promote(ent)
+		return false
+	}
+
+	c.items[key] = c.probation.pushFront(key, value)
+	evict := c.Len() > c.size
+	if evict {
+		c.removeOldest()
+	}
+	return evict
+}
+
+func (c *SLRUCache[K, V]) Contains(key K) (ok bool) {
+	_, ok = c.items[key]
+	return ok
+}
+
+// Peek returns the key value without promoting it.
+func (c *SLRUCache[K, V]) Peek(key K) (value V, ok bool) {
+	if ent, ok := c.items[key]; ok {
+		return ent.value, true
+	}
+	return
+}
+
+func (c *SLRUCache[K, V]) Remove(key K) (present bool) {
+	if ent, ok := c.items[key]; ok {
+		c.removeElement(ent)
+		return true
+	}
+	return false
+}
+
+// Keys returns the keys in eviction order, probation then protected.
+func (c *SLRUCache[K, V]) Keys() []K {
+	keys := make([]K, 0, c.Len())
+	for _, l := range []*lruList[K, V]{c.probation, c.protected} {
+		for ent := l.back(); ent != nil; ent = ent.prevEntry() {
+			keys = append(keys, ent.key)
+		}
+	}
+	return keys
+}
+
+func (c *SLRUCache[K, V]) Len() int {
+	return c.probation.length() + c.protected.length()
+}
+
+func (c *SLRUCache[K, V]) Purge() {
+	for k, v := range c.items {
+		if c.onEvict != nil {
+			c.onEvict(k, v.value)
+		}
+		delete(c.items, k)
+	}
+	c.probation.init()
+	c.protected.init()
+}
+
+// Resize changes the cache size keeping the segment ratio, returns the
+// number of evicted entries.
+func (c *SLRUCache[K, V]) Resize(size int) (evicted int) {
+	c.setSize(size)
+	for c.protected.length() > c.protectedSize {
+		old := c.protected.back()
+		c.protected.remove(old)
+		c.probation.insert(old, &c.probation.root)
+	}
+	for c.Len() > size {
+		c.removeOldest()
+		evicted++
+	}
+	return evicted
+}
+
+// oldest returns the entry that would be evicted next.
+func (c *SLRUCache[K, V]) oldest() *entry[K, V] {
+	if e := c.probation.back(); e != nil {
+		return e
+	}
+	return c.protected.back()
+}
+
+func (c *SLRUCache[K, V]) removeOldest() {
+	if e := c.oldest(); e != nil {
+		c.removeElement(e)
+	}
+}
+
+func (c *SLRUCache[K, V]) removeElement(e *entry[K, V]) {
+	e.list.remove(e)
+	delete(c.items, e.key)
+	if c.onEvict != nil {
+		c.onEvict(e.key, e.value)
+	}
+}
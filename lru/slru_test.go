@@ -0,0 +1,49 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 16:14:20
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-18 16:21:48
+ */
+
+package lru
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSLRU(t *testing.T) {
+	var evicted []int
+	c, err := NewSLRUParams(4, 0.5, func(k int, v int) { evicted = append(evicted, k) })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		c.Add(i, i)
+	}
+	c.Get(0)
+	c.Get(1)
+	if got, want := c.Keys(), []int{2, 3, 0, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys: got %v, want %v", got, want)
+	}
+	// 2 is promoted, the protected segment overflows and 0 is demoted
+	c.Get(2)
+	if got, want := c.Keys(), []int{3, 0, 1, 2}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys: got %v, want %v", got, want)
+	}
+	for i := 4; i < 8; i++ {
+		c.Add(i, i)
+	}
+	if !c.Contains(1) || !c.Contains(2) {
+		t.Fatalf("protected entries should survive: %v", c.Keys())
+	}
+	if got, want := evicted, []int{3, 0, 4, 5}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("evicted: got %v, want %v", got, want)
+	}
+	if evicted := c.Resize(1); evicted != 3 || c.Len() != 1 {
+		t.Fatalf("resize should evict 3 entries: %v", evicted)
+	}
+	if _, err := NewSLRUParams[int, int](4, 2, nil); err == nil {
+		t.Fatalf("expected error for protected ratio")
+	}
+}
@@ -0,0 +1,161 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-19 09:12:26
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-25 12:31:17
+ */
+
+package lru
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// A snapshot starts with a fixed header:
+//
+//	magic   [4]byte "LRUS"
+//	version uint16
+//	hdrLen  uint16, length of the header fields below
+//	count   uint64, number of entries
+//
+// followed by the entries from oldest to newest, written by the codec.
+// Readers skip header fields they do not know, so new fields can be appended
+// without bumping the version.
+const (
+	snapshotMagic   = "LRUS"
+	snapshotVersion = 1
+	snapshotHdrLen  = 8
+)
+
+var ErrBadSnapshot = errors.New("not a cache snapshot")
+
+// Encoder writes the entries of one snapshot.
+type Encoder[K comparable, V any] interface {
+	Encode(key K, value V) error
+}
+
+// Decoder reads the entries of one snapshot.
+type Decoder[K comparable, V any] interface {
+	Decode() (key K, value V, err error)
+}
+
+// Codec serializes the entries of a snapshot.
+type Codec[K comparable, V any] interface {
+	NewEncoder(w io.Writer) Encoder[K, V]
+	NewDecoder(r io.Reader) Decoder[K, V]
+}
+
+// GobCodec encodes entries with encoding/gob, the default codec.
+type GobCodec[K comparable, V any] struct{}
+
+type gobEncoder[K comparable, V any] struct {
+	enc *gob.Encoder
+}
+
+func (e gobEncoder[K, V]) Encode(key K, value V) error {
+	if err := e.enc.Encode(&key); err != nil {
+		return err
+	}
+	return e.enc.Encode(&value)
+}
+
+type gobDecoder[K comparable, V any] struct {
+	dec *gob.Decoder
+}
+
+func (d gobDecoder[K, V]) Decode() (key K, value V, err error) {
+	if err = d.dec.Decode(&key); err != nil {
+		return
+	}
+	err = d.dec.Decode(&value)
+	return
+}
+
+func (GobCodec[K, V]) NewEncoder(w io.Writer) Encoder[K, V] {
+	return gobEncoder[K, V]{enc: gob.NewEncoder(w)}
+}
+
+func (GobCodec[K, V]) NewDecoder(r io.Reader) Decoder[K, V] {
+	return gobDecoder[K, V]{dec: gob.NewDecoder(r)}
+}
+
+// SaveTo writes the entries of the cache, gob encoded, in recency order.
+func (c *Cache[K, V]) SaveTo(w io.Writer) error {
+	return c.SaveToWithCodec(w, GobCodec[K, V]{})
+}
+
+// SaveToWithCodec writes the entries of the cache with codec, in recency
+// order.
+func (c *Cache[K, V]) SaveToWithCodec(w io.Writer, codec Codec[K, V]) error {
+	bw := bufio.NewWriter(w)
+	var hdr [8 + snapshotHdrLen]byte
+	copy(hdr[:4], snapshotMagic)
+	binary.BigEndian.PutUint16(hdr[4:], snapshotVersion)
+	binary.BigEndian.PutUint16(hdr[6:], snapshotHdrLen)
+	binary.BigEndian.PutUint64(hdr[8:], uint64(c.evictList.length()))
+	if _, err := bw.Write(hdr[:]); err != nil {
+		return err
+	}
+
+	enc := codec.NewEncoder(bw)
+	for ent := c.evictList.back(); ent != nil; ent = ent.prevEntry() {
+		if err := enc.Encode(ent.key, ent.value); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// LoadFrom adds the entries of a gob encoded snapshot to the cache. Entries
+// are added from oldest to newest, so they keep their eviction order, become
+// more recent than the entries already cached, and the oldest ones are
+// evicted if the snapshot is larger than the cache.
+func (c *Cache[K, V]) LoadFrom(r io.Reader) error {
+	return c.LoadFromWithCodec(r, GobCodec[K, V]{})
+}
+
+// LoadFromWithCodec is LoadFrom for snapshots written with codec. The whole
+// snapshot is decoded before any entry is added, a bad one leaves the cache
+// as it was.
+func (c *Cache[K, V]) LoadFromWithCodec(r io.Reader, codec Codec[K, V]) error {
+	br := bufio.NewReader(r)
+	var hdr [8]byte
+	if _, err := io.ReadFull(br, hdr[:]); err != nil {
+		return ErrBadSnapshot
+	}
+	if string(hdr[:4]) != snapshotMagic {
+		return ErrBadSnapshot
+	}
+	if v := binary.BigEndian.Uint16(hdr[4:]); v == 0 || v > snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", v)
+	}
+	fields := make([]byte, binary.BigEndian.Uint16(hdr[6:]))
+	if len(fields) < snapshotHdrLen {
+		return ErrBadSnapshot
+	}
+	if _, err := io.ReadFull(br, fields); err != nil {
+		return ErrBadSnapshot
+	}
+	count := binary.BigEndian.Uint64(fields)
+
+	// count is not trusted to size the buffer, a corrupt one would not fail
+	// before a large allocation
+	var entries []entry[K, V]
+	dec := codec.NewDecoder(br)
+	for i := uint64(0); i < count; i++ {
+		key, value, err := dec.Decode()
+		if err != nil {
+			return fmt.Errorf("decode entry %d: %w", i, err)
+		}
+		entries = append(entries, entry[K, V]{key: key, value: value})
+	}
+	for _, ent := range entries {
+		c.Add(ent.key, ent.value)
+	}
+	return nil
+}
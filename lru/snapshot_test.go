@@ -0,0 +1,158 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-19 09:40:08
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-25 12:31:17
+ */
+
+package lru
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	c, _ := New[string, int](4, nil)
+	for i, k := range []string{"a", "b", "c", "d"} {
+		c.Add(k, i)
+	}
+	c.Get("a")
+
+	var buf bytes.Buffer
+	if err := c.SaveTo(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	restored, _ := New[string, int](4, nil)
+	if err := restored.LoadFrom(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if got, want := restored.Keys(), c.Keys(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys: got %v, want %v", got, want)
+	}
+	// the same key is evicted next
+	c.Add("e", 4)
+	restored.Add("e", 4)
+	if got, want := restored.Keys(), c.Keys(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys after add: got %v, want %v", got, want)
+	}
+	if v, ok := restored.Peek("a"); !ok || v != 0 {
+		t.Fatalf("bad value for a: %v", v)
+	}
+
+	// a smaller cache keeps the most recent entries
+	small, _ := New[string, int](2, nil)
+	small.LoadFrom(bytes.NewReader(buf.Bytes()))
+	if got, want := small.Keys(), []string{"d", "a"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys: got %v, want %v", got, want)
+	}
+}
+
+type jsonCodec struct{}
+
+type jsonEntry struct {
+	K string
+	V int
+}
+
+type jsonEncoder struct{ enc *json.Encoder }
+
+func (e jsonEncoder) Encode(key string, value int) error {
+	return e.enc.Encode(jsonEntry{key, value})
+}
+
+type jsonDecoder struct{ dec *json.Decoder }
+
+func (d jsonDecoder) Decode() (string, int, error) {
+	var e jsonEntry
+	err := d.dec.Decode(&e)
+	return e.K, e.V, err
+}
+
+func (jsonCodec) NewEncoder(w io.Writer) Encoder[string, int] {
+	return jsonEncoder{json.NewEncoder(w)}
+}
+
+func (jsonCodec) NewDecoder(r io.Reader) Decoder[string, int] {
+	return jsonDecoder{json.NewDecoder(r)}
+}
+
+func TestSnapshotCodec(t *testing.T) {
+	c, _ := New[string, int](4, nil)
+	c.Add("x", 1)
+	c.Add("y", 2)
+	var buf bytes.Buffer
+	if err := c.SaveToWithCodec(&buf, jsonCodec{}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`{"K":"y","V":2}`)) {
+		t.Fatalf("entries should be json encoded: %q", buf.Bytes())
+	}
+	restored, _ := New[string, int](4, nil)
+	if err := restored.LoadFromWithCodec(&buf, jsonCodec{}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if got, want := restored.Keys(), []string{"x", "y"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys: got %v, want %v", got, want)
+	}
+}
+
+func TestSnapshotHeader(t *testing.T) {
+	c, _ := New[int, int](4, nil)
+	if err := c.LoadFrom(bytes.NewReader([]byte("nope, not a snapshot"))); !errors.Is(err, ErrBadSnapshot) {
+		t.Fatalf("expected ErrBadSnapshot: %v", err)
+	}
+
+	src, _ := New[int, int](4, nil)
+	src.Add(1, 1)
+	var buf bytes.Buffer
+	src.SaveTo(&buf)
+	data := buf.Bytes()
+
+	newer := append([]byte(nil), data...)
+	binary.BigEndian.PutUint16(newer[4:], snapshotVersion+1)
+	if err := c.LoadFrom(bytes.NewReader(newer)); err == nil {
+		t.Fatalf("expected error for a newer version")
+	}
+
+	zero := append([]byte(nil), data...)
+	binary.BigEndian.PutUint16(zero[4:], 0)
+	if err := c.LoadFrom(bytes.NewReader(zero)); err == nil || c.Len() != 0 {
+		t.Fatalf("expected error for version 0")
+	}
+
+	// unknown header fields appended by a later writer are skipped
+	extended := append([]byte(nil), data[:16]...)
+	binary.BigEndian.PutUint16(extended[6:], snapshotHdrLen+4)
+	extended = append(extended, 0xde, 0xad, 0xbe, 0xef)
+	extended = append(extended, data[16:]...)
+	if err := c.LoadFrom(bytes.NewReader(extended)); err != nil || !c.Contains(1) {
+		t.Fatalf("extended header should load: %v", err)
+	}
+}
+
+func TestSnapshotTruncated(t *testing.T) {
+	src, _ := New[int, int](8, nil)
+	for i := 0; i < 8; i++ {
+		src.Add(i, i)
+	}
+	var buf bytes.Buffer
+	src.SaveTo(&buf)
+	data := buf.Bytes()
+
+	c, _ := New[int, int](4, nil)
+	c.Add(100, 100)
+	c.Add(101, 101)
+	want := c.Keys()
+	if err := c.LoadFrom(bytes.NewReader(data[:len(data)-4])); err == nil {
+		t.Fatalf("expected error for a truncated snapshot")
+	}
+	if got := c.Keys(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("a failed load should leave the cache alone: %v", got)
+	}
+}
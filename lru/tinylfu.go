@@ -0,0 +1,204 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 17:05:51
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-18 17:36:02
+ */
+
+package lru
+
+import (
+	"errors"
+	"hash/maphash"
+)
+
+// DefaultTinyLFUWindowRatio is the share of the cache used by the window LRU.
+const DefaultTinyLFUWindowRatio = 0.01
+
+// TinyLFUCache is a W-TinyLFU cache. New keys enter a small window LRU, and
+// an entry pushed out of the window only replaces the next victim of the main
+// SLRU if the count-min sketch estimates it was accessed more often. Bursts
+// are absorbed by the window while the main cache stays scan resistant. It is
+// not safe for concurrent use.
+type TinyLFUCache[K comparable, V any] struct {
+	size       int
+	windowSize int
+
+	window *lruList[K, V]
+	items  map[K]*entry[K, V]
+	main   *SLRUCache[K, V]
+
+	seed    maphash.Seed
+	sketch  *cmSketch
+	onEvict EvictCallback[K, V]
+}
+
+var _ Policy[int, int] = (*TinyLFUCache[int, int])(nil)
+
+func NewTinyLFU[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*TinyLFUCache[K, V], error) {
+	if size < 2 {
+		return nil, errors.New("size must be at least 2")
+	}
+	c := &TinyLFUCache[K, V]{
+		window:  newList[K, V](),
+		items:   make(map[K]*entry[K, V]),
+		seed:    maphash.MakeSeed(),
+		sketch:  newCMSketch(size),
+		onEvict: onEvict,
+	}
+	windowSize, mainSize := c.split(size)
+	main, err := NewSLRU[K, V](mainSize, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.size, c.windowSize, c.main = size, windowSize, main
+	return c, nil
+}
+
+func (c *TinyLFUCache[K, V]) split(size int) (window, main int) {
+	window = min(max(int(float64(size)*DefaultTinyLFUWindowRatio), 1), size)
+	return window, size - window
+}
+
+func (c *TinyLFUCache[K, V]) hash(key K) uint64 {
+	return maphash.Comparable(c.seed, key)
+}
+
+func (c *TinyLFUCache[K, V]) Get(key K) (value V, ok bool) {
+	c.sketch.increment(c.hash(key))
+	if ent, ok := c.items[key]; ok {
+		c.window.moveToFront(ent)
+		return ent.value, true
+	}
+	if ent, ok := c.main.items[key]; ok {
+		c.main.promote(ent)
+		return ent.value, true
+	}
+	return
+}
+
+// Add adds a value to the cache, returns true if an eviction occurred. The
+// evicted entry may be the candidate leaving the window, if it lost the
+// admission against the main cache victim.
+func (c *TinyLFUCache[K, V]) Add(key K, value V) (evicted bool) {
+	c.sketch.increment(c.hash(key))
+	if ent, ok := c.items[key]; ok {
+		ent.value = value
+		c.window.moveToFront(ent)
+		return false
+	}
+	if ent, ok := c.main.items[key]; ok {
+		ent.value = value
+		c.main.promote(ent)
+		return false
+	}
+
+	c.items[key] = c.window.pushFront(key, value)
+	for c.window.length() > c.windowSize {
+		if c.admit(c.window.back()) {
+			evicted = true
+		}
+	}
+	return evicted
+}
+
+// admit moves the candidate from the window to the main cache, returns true
+// if either the candidate or the main victim had to be evicted.
+func (c *TinyLFUCache[K, V]) admit(candidate *entry[K, V]) bool {
+	c.window.remove(candidate)
+	delete(c.items, candidate.key)
+
+	if c.main.Len() < c.main.size {
+		c.main.items[candidate.key] = c.main.probation.pushFront(candidate.key, candidate.value)
+		return false
+	}
+	victim := c.main.oldest()
+	if victim == nil || c.sketch.estimate(c.hash(candidate.key)) <= c.sketch.estimate(c.hash(victim.key)) {
+		c.evicted(candidate.key, candidate.value)
+		return true
+	}
+	c.main.removeElement(victim)
+	c.evicted(victim.key, victim.value)
+	c.main.items[candidate.key] = c.main.probation.pushFront(candidate.key, candidate.value)
+	return true
+}
+
+func (c *TinyLFUCache[K, V]) evicted(key K, value V) {
+	if c.onEvict != nil {
+		c.onEvict(key, value)
+	}
+}
+
+func (c *TinyLFUCache[K, V]) Contains(key K) (ok bool) {
+	if _, ok = c.items[key]; ok {
+		return true
+	}
+	return c.main.Contains(key)
+}
+
+// Peek returns the key value without recording an access.
+func (c *TinyLFUCache[K, V]) Peek(key K) (value V, ok bool) {
+	if ent, ok := c.items[key]; ok {
+		return ent.value, true
+	}
+	return c.main.Peek(key)
+}
+
+func (c *TinyLFUCache[K, V]) Remove(key K) (present bool) {
+	if ent, ok := c.items[key]; ok {
+		c.window.remove(ent)
+		delete(c.items, key)
+		c.evicted(ent.key, ent.value)
+		return true
+	}
+	if ent, ok := c.main.items[key]; ok {
+		c.main.removeElement(ent)
+		c.evicted(ent.key, ent.value)
+		return true
+	}
+	return false
+}
+
+// Keys returns the window keys then the main cache keys, each in eviction
+// order.
+func (c *TinyLFUCache[K, V]) Keys() []K {
+	keys := make([]K, 0, c.Len())
+	for ent := c.window.back(); ent != nil; ent = ent.prevEntry() {
+		keys = append(keys, ent.key)
+	}
+	return append(keys, c.main.Keys()...)
+}
+
+func (c *TinyLFUCache[K, V]) Len() int {
+	return c.window.length() + c.main.Len()
+}
+
+// Purge empties the cache, the access history in the sketch is kept.
+func (c *TinyLFUCache[K, V]) Purge() {
+	for k, v := range c.items {
+		c.evicted(k, v.value)
+		delete(c.items, k)
+	}
+	c.window.init()
+	c.main.onEvict = c.onEvict
+	c.main.Purge()
+	c.main.onEvict = nil
+}
+
+// Resize changes the cache size, returns the number of evicted entries. The
+// sketch keeps the width it was created with.
+func (c *TinyLFUCache[K, V]) Resize(size int) (evicted int) {
+	windowSize, mainSize := c.split(size)
+	c.size, c.windowSize = size, windowSize
+	for c.window.length() > c.windowSize {
+		ent := c.window.back()
+		c.window.remove(ent)
+		delete(c.items, ent.key)
+		c.evicted(ent.key, ent.value)
+		evicted++
+	}
+	c.main.onEvict = c.onEvict
+	evicted += c.main.Resize(mainSize)
+	c.main.onEvict = nil
+	return evicted
+}
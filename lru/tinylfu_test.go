@@ -0,0 +1,148 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 17:21:37
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-18 17:36:02
+ */
+
+package lru
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestCMSketch(t *testing.T) {
+	s := newCMSketch(64)
+	h := uint64(0x1234567890abcdef)
+	if n := s.estimate(h); n != 0 {
+		t.Fatalf("unseen key should estimate 0: %v", n)
+	}
+	// the first access only reaches the doorkeeper
+	s.increment(h)
+	if n := s.estimate(h); n != 1 || s.count(h) != 0 {
+		t.Fatalf("bad estimate after doorkeeper: %v", n)
+	}
+	for i := 0; i < 4; i++ {
+		s.increment(h)
+	}
+	if n := s.estimate(h); n != 5 {
+		t.Fatalf("bad estimate: %v", n)
+	}
+	for i := 0; i < 100; i++ {
+		s.increment(h)
+	}
+	if n := s.count(h); n > cmMaxCount {
+		t.Fatalf("counter should saturate: %v", n)
+	}
+
+	// aging halves the counters and clears the doorkeeper
+	before := s.count(h)
+	s.reset()
+	if n := s.count(h); n != before/2 || s.door.contains(h) {
+		t.Fatalf("bad count after reset: %v, want %v", n, before/2)
+	}
+}
+
+func TestCMSketchAging(t *testing.T) {
+	s := newCMSketch(16)
+	h := uint64(42)
+	for i := 0; i < 10; i++ {
+		s.increment(h)
+	}
+	for i := 0; i < s.resetAt; i++ {
+		s.increment(uint64(rand.Int63()))
+	}
+	if n := s.estimate(h); n >= 10 {
+		t.Fatalf("old accesses should fade: %v", n)
+	}
+}
+
+func TestTinyLFU(t *testing.T) {
+	evictCounter := 0
+	c, err := NewTinyLFU(100, func(k int, v int) {
+		if k != v {
+			t.Fatalf("Evict values not equal (%v!=%v)", k, v)
+		}
+		evictCounter++
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		c.Add(i, i)
+	}
+	if c.Len() != 100 || evictCounter != 0 {
+		t.Fatalf("bad len %v or evict count %v", c.Len(), evictCounter)
+	}
+	// make the first half popular
+	for r := 0; r < 5; r++ {
+		for i := 0; i < 50; i++ {
+			if v, ok := c.Get(i); !ok || v != i {
+				t.Fatalf("bad value for %v: %v", i, v)
+			}
+		}
+	}
+	// a scan of one hit wonders is rejected by the admission filter
+	for i := 1000; i < 2000; i++ {
+		c.Add(i, i)
+	}
+	for i := 0; i < 50; i++ {
+		if !c.Contains(i) {
+			t.Fatalf("popular key %v should have been kept", i)
+		}
+	}
+	if c.Len() != 100 || evictCounter != 1000 {
+		t.Fatalf("bad len %v or evict count %v", c.Len(), evictCounter)
+	}
+
+	if !c.Remove(0) || c.Contains(0) {
+		t.Fatalf("0 should be removed")
+	}
+	if evicted := c.Resize(10); evicted != 89 || c.Len() != 10 {
+		t.Fatalf("resize should evict 89 entries: %v, len %v", evicted, c.Len())
+	}
+	c.Purge()
+	if c.Len() != 0 || evictCounter != 1100 {
+		t.Fatalf("bad len %v or evict count %v", c.Len(), evictCounter)
+	}
+}
+
+// callers switch policies by constructor only
+func TestPolicies(t *testing.T) {
+	constructors := map[string]func(int) (Policy[int, int], error){
+		"lru":     func(n int) (Policy[int, int], error) { return New[int, int](n, nil) },
+		"lfu":     func(n int) (Policy[int, int], error) { return NewLFU[int, int](n, nil) },
+		"fifo":    func(n int) (Policy[int, int], error) { return NewFIFO[int, int](n, nil) },
+		"clock":   func(n int) (Policy[int, int], error) { return NewClock[int, int](n, nil) },
+		"arc":     func(n int) (Policy[int, int], error) { return NewARC[int, int](n, nil) },
+		"2q":      func(n int) (Policy[int, int], error) { return New2Q[int, int](n, nil) },
+		"slru":    func(n int) (Policy[int, int], error) { return NewSLRU[int, int](n, nil) },
+		"tinylfu": func(n int) (Policy[int, int], error) { return NewTinyLFU[int, int](n, nil) },
+	}
+	for name, newPolicy := range constructors {
+		t.Run(name, func(t *testing.T) {
+			c, err := newPolicy(32)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			for i := 0; i < 1000; i++ {
+				k := rand.Intn(64)
+				if v, ok := c.Get(k); ok && v != k {
+					t.Fatalf("bad value for %v: %v", k, v)
+				}
+				c.Add(k, k)
+				if c.Len() > 32 {
+					t.Fatalf("bad len: %v", c.Len())
+				}
+			}
+			if len(c.Keys()) != c.Len() {
+				t.Fatalf("keys and len disagree: %v, %v", len(c.Keys()), c.Len())
+			}
+			c.Purge()
+			if c.Len() != 0 {
+				t.Fatalf("bad len after purge: %v", c.Len())
+			}
+		})
+	}
+}
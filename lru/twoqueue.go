@@ -0,0 +1,236 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 15:30:12
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-25 11:02:17
+ */
+
+package lru
+
+import (
+	"errors"
+)
+
+const (
+	// Default2QRecentRatio is the share of the cache used by A1in.
+	Default2QRecentRatio = 0.25
+	// Default2QGhostEntries is the size of A1out relative to the cache.
+	Default2QGhostEntries = 0.50
+)
+
+// TwoQueueCache is a simplified 2Q cache. New keys enter the FIFO a1in, keys
+// evicted from it are remembered in the ghost FIFO a1out, and only a key hit
+// again while in a1in or a1out is promoted to the LRU am. A one time scan
+// therefore never pushes out the frequently used entries. The full 2Q leaves
+// a key hit in a1in where it is, which takes tuning a1in to the time between
+// correlated references. It is not safe for concurrent use.
+type TwoQueueCache[K comparable, V any] struct {
+	size       int
+	recentSize int
+	ghostSize  int
+
+	recentRatio float64
+	ghostRatio  float64
+
+	a1in, a1out, am *lruList[K, V]
+
+	items   map[K]*entry[K, V]
+	onEvict EvictCallback[K, V]
+}
+
+var _ Policy[int, int] = (*TwoQueueCache[int, int])(nil)
+
+func New2Q[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*TwoQueueCache[K, V], error) {
+	return New2QParams(size, Default2QRecentRatio, Default2QGhostEntries, onEvict)
+}
+
+// New2QParams creates a 2Q cache where recentRatio sizes a1in and
+// ghostRatio sizes a1out, both relative to size.
+func New2QParams[K comparable, V any](size int, recentRatio, ghostRatio float64, onEvict EvictCallback[K, V]) (*TwoQueueCache[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	if recentRatio < 0.0 || recentRatio > 1.0 {
+		return nil, errors.New("invalid recent ratio")
+	}
+	if ghostRatio < 0.0 || ghostRatio > 1.0 {
+		return nil, errors.New("invalid ghost ratio")
+	}
+	c := &TwoQueueCache[K, V]{
+		recentRatio: recentRatio,
+		ghostRatio:  ghostRatio,
+		a1in:        newList[K, V](),
+		a1out:       newList[K, V](),
+		am:          newList[K, V](),
+		items:       make(map[K]*entry[K, V]),
+		onEvict:     onEvict,
+	}
+	c.setSize(size)
+	return c, nil
+}
+
+func (c *TwoQueueCache[K, V]) setSize(size int) {
+	c.size = size
+	c.recentSize = int(float64(size) * c.recentRatio)
+	c.ghostSize = int(float64(size) * c.ghostRatio)
+}
+
+func (c *TwoQueueCache[K, V]) resident(e *entry[K, V]) bool {
+	return e.list == c.a1in || e.list == c.am
+}
+
+func (c *TwoQueueCache[K, V]) moveTo(e *entry[K, V], l *lruList[K, V]) {
+	if e.list == l {
+		l.moveToFront(e)
+		return
+	}
+	e.list.remove(e)
+	l.insert(e, &l.root)
+}
+
+func (c *TwoQueueCache[K, V]) Get(key K) (value V, ok bool) {
+	if ent, ok := c.items[key]; ok && c.resident(ent) {
+		c.moveTo(ent, c.am)
+		return ent.value, true
+	}
+	return
+}
+
+// Add adds a value to the cache, returns true if an eviction occurred.
+func (c *TwoQueueCache[K, V]) Add(key K, value V) (evicted bool) {
+	if ent, ok := c.items[key]; ok {
+		if c.resident(ent) {
+			ent.value = value
+			c.moveTo(ent, c.am)
+			return false
+		}
+		// seen recently, the key goes straight to am. The ghost is dropped
+		// first so making room cannot trim it from a1out.
+		c.removeGhost(ent)
+		evicted = c.ensureSpace(true)
+		c.items[key] = c.am.pushFront(key, value)
+		return evicted
+	}
+
+	evicted = c.ensureSpace(false)
+	c.items[key] = c.a1in.pushFront(key, value)
+	return evicted
+}
+
+// ensureSpace makes room for one more resident entry. a1in is drained while
+// it is above its share, otherwise the oldest entry of am is evicted.
+func (c *TwoQueueCache[K, V]) ensureSpace(ghostHit bool) bool {
+	if c.a1in.length()+c.am.length() < c.size {
+		return false
+	}
+	recent := c.a1in.length()
+	if recent > 0 && (recent > c.recentSize || (recent == c.recentSize && !ghostHit) || c.am.length() == 0) {
+		e := c.a1in.back()
+		key, value := e.key, e.value
+		if c.ghostSize > 0 {
+			var zero V
+			e.value = zero
+			c.moveTo(e, c.a1out)
+			for c.a1out.length() > c.ghostSize {
+				c.removeGhost(c.a1out.back())
+			}
+		} else {
+			c.a1in.remove(e)
+			delete(c.items, key)
+		}
+		if c.onEvict != nil {
+			c.onEvict(key, value)
+		}
+		return true
+	}
+	if c.am.length() == 0 {
+		return false
+	}
+	c.removeElement(c.am.back())
+	return true
+}
+
+func (c *TwoQueueCache[K, V]) removeGhost(e *entry[K, V]) {
+	e.list.remove(e)
+	delete(c.items, e.key)
+}
+
+func (c *TwoQueueCache[K, V]) Contains(key K) (ok bool) {
+	ent, ok := c.items[key]
+	return ok && c.resident(ent)
+}
+
+// Peek returns the key value without promoting it.
+func (c *TwoQueueCache[K, V]) Peek(key K) (value V, ok bool) {
+	if ent, ok := c.items[key]; ok && c.resident(ent) {
+		return ent.value, true
+	}
+	return
+}
+
+// Remove removes the key from the cache, including its ghost entry.
+func (c *TwoQueueCache[K, V]) Remove(key K) (present bool) {
+	ent, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	if !c.resident(ent) {
+		c.removeGhost(ent)
+		return false
+	}
+	c.removeElement(ent)
+	return true
+}
+
+// Keys returns the resident keys, a1in then am, each from oldest to newest.
+func (c *TwoQueueCache[K, V]) Keys() []K {
+	keys := make([]K, 0, c.Len())
+	for _, l := range []*lruList[K, V]{c.a1in, c.am} {
+		for ent := l.back(); ent != nil; ent = ent.prevEntry() {
+			keys = append(keys, ent.key)
+		}
+	}
+	return keys
+}
+
+func (c *TwoQueueCache[K, V]) Len() int {
+	return c.a1in.length() + c.am.length()
+}
+
+func (c *TwoQueueCache[K, V]) Purge() {
+	for k, v := range c.items {
+		if c.onEvict != nil && c.resident(v) {
+			c.onEvict(k, v.value)
+		}
+		delete(c.items, k)
+	}
+	c.a1in.init()
+	c.a1out.init()
+	c.am.init()
+}
+
+// Resize changes the cache size keeping the segment ratios, returns the
+// number of evicted entries. A size which is not positive is ignored, like
+// New2QParams rejects it.
+func (c *TwoQueueCache[K, V]) Resize(size int) (evicted int) {
+	if size <= 0 {
+		return 0
+	}
+	c.setSize(size)
+	for c.Len() > size {
+		c.ensureSpace(false)
+		evicted++
+	}
+	for c.a1out.length() > c.ghostSize {
+		c.removeGhost(c.a1out.back())
+	}
+	return evicted
+}
+
+func (c *TwoQueueCache[K, V]) removeElement(e *entry[K, V]) {
+	e.list.remove(e)
+	delete(c.items, e.key)
+	if c.onEvict != nil {
+		c.onEvict(e.key, e.value)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 16:05:33
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-25 11:02:17
+ */
+
+package lru
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func Test2Q(t *testing.T) {
+	evictCounter := 0
+	c, err := New2Q(128, func(k int, v int) {
+		if k != v {
+			t.Fatalf("Evict values not equal (%v!=%v)", k, v)
+		}
+		evictCounter++
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 256; i++ {
+		c.Add(i, i)
+	}
+	if c.Len() != 128 || evictCounter != 128 {
+		t.Fatalf("bad len %v or evict count %v", c.Len(), evictCounter)
+	}
+	if c.a1out.length() != 64 {
+		t.Fatalf("a1out should be full: %v", c.a1out.length())
+	}
+	for i, k := range c.Keys() {
+		if v, ok := c.Get(k); !ok || v != k || v != i+128 {
+			t.Fatalf("bad key: %v", k)
+		}
+	}
+	if c.am.length() != 128 {
+		t.Fatalf("hits should promote every entry to am")
+	}
+	c.Purge()
+	if c.Len() != 0 || len(c.items) != 0 {
+		t.Fatalf("cache should be empty")
+	}
+}
+
+func Test2QGhostPromotion(t *testing.T) {
+	c, _ := New2QParams[int, int](4, 0.5, 1.0, nil)
+	for i := 0; i < 6; i++ {
+		c.Add(i, i)
+	}
+	// 0 and 1 were pushed out of a1in and are remembered in a1out
+	if c.Contains(0) || c.items[0].list != c.a1out {
+		t.Fatalf("0 should be a ghost")
+	}
+	c.Add(0, 0)
+	if c.items[0].list != c.am {
+		t.Fatalf("ghost hit should go to am")
+	}
+
+	// a scan only churns a1in
+	for i := 100; i < 200; i++ {
+		c.Add(i, i)
+	}
+	if !c.Contains(0) {
+		t.Fatalf("0 should survive the scan: %v", c.Keys())
+	}
+	if c.Remove(1) {
+		t.Fatalf("removing a ghost should not report a resident entry")
+	}
+}
+
+func Test2QRandomOps(t *testing.T) {
+	c, _ := New2Q[int, int](64, nil)
+	for i := 0; i < 100000; i++ {
+		key := rand.Int() % 256
+		switch rand.Int() % 3 {
+		case 0:
+			c.Add(key, key)
+		case 1:
+			c.Get(key)
+		case 2:
+			c.Remove(key)
+		}
+		if c.Len() > 64 || c.a1out.length() > c.ghostSize {
+			t.Fatalf("bad sizes: len %v, a1out %v", c.Len(), c.a1out.length())
+		}
+	}
+	if evicted := c.Resize(8); c.Len() > 8 || evicted < 0 {
+		t.Fatalf("bad resize: len %v", c.Len())
+	}
+	n := c.Len()
+	for _, size := range []int{0, -1} {
+		if evicted := c.Resize(size); evicted != 0 || c.Len() != n || c.size != 8 {
+			t.Fatalf("resize to %v should be ignored: len %v", size, c.Len())
+		}
+	}
+}
+
+func Test2QInvalidParams(t *testing.T) {
+	if _, err := New2QParams[int, int](4, 1.5, 0.5, nil); err == nil {
+		t.Fatalf("expected error for recent ratio")
+	}
+	if _, err := New2QParams[int, int](4, 0.5, -1, nil); err == nil {
+		t.Fatalf("expected error for ghost ratio")
+	}
+}
@@ -0,0 +1,178 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 20:51:30
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-24 10:05:52
+ */
+
+package lru
+
+import (
+	"errors"
+)
+
+// ErrCostTooLarge is returned when an entry costs more than the whole cache.
+var ErrCostTooLarge = errors.New("entry cost exceeds the cache capacity")
+
+// CostFunc returns the cost of an entry, e.g. its size in bytes.
+type CostFunc[K comparable, V any] func(key K, value V) int64
+
+// weightedValue is a value with its cost.
+type weightedValue[V any] struct {
+	value V
+	cost  int64
+}
+
+// WeightedCache is a LRU cache bounded by the total cost of its entries
+// instead of their count. It is not safe for concurrent use.
+type WeightedCache[K comparable, V any] struct {
+	maxCost   int64
+	cost      int64
+	costFunc  CostFunc[K, V]
+	evictList *lruList[K, weightedValue[V]]
+	items     map[K]*entry[K, weightedValue[V]]
+	onEvict   EvictCallback[K, V]
+}
+
+var _ Policy[int, int] = (*WeightedCache[int, int])(nil)
+
+func NewWeighted[K comparable, V any](maxCost int64, costFunc CostFunc[K, V], onEvict EvictCallback[K, V]) (*WeightedCache[K, V], error) {
+	if maxCost <= 0 {
+		return nil, errors.New("must provide a positive max cost")
+	}
+	if costFunc == nil {
+		return nil, errors.New("must provide a cost function")
+	}
+	return &WeightedCache[K, V]{
+		maxCost:   maxCost,
+		costFunc:  costFunc,
+		evictList: newList[K, weightedValue[V]](),
+		items:     make(map[K]*entry[K, weightedValue[V]]),
+		onEvict:   onEvict,
+	}, nil
+}
+
+// TryAdd adds a value to the cache, evicting as many entries as needed to
+// stay within the max cost. It returns ErrCostTooLarge if the value alone
+// costs more than the max cost, and removes the old value of the key so it is
+// not returned in place of the rejected one.
+func (c *WeightedCache[K, V]) TryAdd(key K, value V) (evicted bool, err error) {
+	cost := c.costFunc(key, value)
+	if cost < 0 {
+		return false, errors.New("negative entry cost")
+	}
+	if cost > c.maxCost {
+		if ent, ok := c.items[key]; ok {
+			c.removeElement(ent)
+		}
+		return false, ErrCostTooLarge
+	}
+
+	ent, ok := c.items[key]
+	if ok {
+		c.evictList.moveToFront(ent)
+		c.cost += cost - ent.value.cost
+		ent.value = weightedValue[V]{value: value, cost: cost}
+	} else {
+		ent = c.evictList.pushFront(key, weightedValue[V]{value: value, cost: cost})
+		c.items[key] = ent
+		c.cost += cost
+	}
+	for c.cost > c.maxCost {
+		// the new entry is at the front and fits alone, so it is never the
+		// one evicted
+		c.removeOldest()
+		evicted = true
+	}
+	return evicted, nil
+}
+
+// Add adds a value to the cache, returns true if an eviction occurred.
+// Values costing more than the max cost are dropped along with the old value
+// of the key, see TryAdd.
+func (c *WeightedCache[K, V]) Add(key K, value V) (evicted bool) {
+	evicted, _ = c.TryAdd(key, value)
+	return evicted
+}
+
+func (c *WeightedCache[K, V]) Get(key K) (value V, ok bool) {
+	if ent, ok := c.items[key]; ok {
+		c.evictList.moveToFront(ent)
+		return ent.value.value, true
+	}
+	return
+}
+
+func (c *WeightedCache[K, V]) Contains(key K) (ok bool) {
+	_, ok = c.items[key]
+	return ok
+}
+
+func (c *WeightedCache[K, V]) Peek(key K) (value V, ok bool) {
+	if ent, ok := c.items[key]; ok {
+		return ent.value.value, true
+	}
+	return
+}
+
+func (c *WeightedCache[K, V]) Remove(key K) (present bool) {
+	if ent, ok := c.items[key]; ok {
+		c.removeElement(ent)
+		return true
+	}
+	return false
+}
+
+// Keys returns a slice of the keys in the cache, from oldest to newest.
+func (c *WeightedCache[K, V]) Keys() []K {
+	keys := make([]K, 0, c.evictList.length())
+	for ent := c.evictList.back(); ent != nil; ent = ent.prevEntry() {
+		keys = append(keys, ent.key)
+	}
+	return keys
+}
+
+func (c *WeightedCache[K, V]) Len() int {
+	return c.evictList.length()
+}
+
+// Cost returns the total cost of the cached entries.
+func (c *WeightedCache[K, V]) Cost() int64 {
+	return c.cost
+}
+
+func (c *WeightedCache[K, V]) Purge() {
+	for k, v := range c.items {
+		if c.onEvict != nil {
+			c.onEvict(k, v.value.value)
+		}
+		delete(c.items, k)
+	}
+	c.evictList.init()
+	c.cost = 0
+}
+
+// Resize changes the max cost, returns the number of evicted entries.
+func (c *WeightedCache[K, V]) Resize(maxCost int) (evicted int) {
+	c.maxCost = int64(maxCost)
+	for c.cost > c.maxCost && c.evictList.length() > 0 {
+		c.removeOldest()
+		evicted++
+	}
+	return evicted
+}
+
+func (c *WeightedCache[K, V]) removeOldest() {
+	if ent := c.evictList.back(); ent != nil {
+		c.removeElement(ent)
+	}
+}
+
+func (c *WeightedCache[K, V]) removeElement(e *entry[K, weightedValue[V]]) {
+	c.evictList.remove(e)
+	delete(c.items, e.key)
+	c.cost -= e.value.cost
+	if c.onEvict != nil {
+		c.onEvict(e.key, e.value.value)
+	}
+}
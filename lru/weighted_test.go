@@ -0,0 +1,83 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-18 21:10:44
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-24 10:05:52
+ */
+
+package lru
+
+import (
+	"reflect"
+	"testing"
+)
+
+func byteLen(key string, value []byte) int64 {
+	return int64(len(value))
+}
+
+func TestWeighted(t *testing.T) {
+	var evicted []string
+	c, err := NewWeighted(10, byteLen, func(k string, v []byte) { evicted = append(evicted, k) })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.Add("a", make([]byte, 3))
+	c.Add("b", make([]byte, 3))
+	c.Add("c", make([]byte, 3))
+	c.Get("a")
+	if c.Cost() != 9 || c.Len() != 3 {
+		t.Fatalf("bad cost %v or len %v", c.Cost(), c.Len())
+	}
+
+	// "d" needs two entries to go
+	if !c.Add("d", make([]byte, 6)) {
+		t.Fatalf("adding d should evict")
+	}
+	if got, want := c.Keys(), []string{"a", "d"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys: got %v, want %v", got, want)
+	}
+	if got, want := evicted, []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("evicted: got %v, want %v", got, want)
+	}
+
+	// updating an entry changes the total cost
+	c.Add("a", make([]byte, 1))
+	if c.Cost() != 7 {
+		t.Fatalf("bad cost: %v", c.Cost())
+	}
+
+	if _, err := c.TryAdd("e", make([]byte, 11)); err != ErrCostTooLarge {
+		t.Fatalf("expected ErrCostTooLarge: %v", err)
+	}
+	if c.Add("e", make([]byte, 11)) || c.Contains("e") || c.Len() != 2 {
+		t.Fatalf("too large entries should be rejected")
+	}
+
+	// a rejected update drops the old value
+	evicted = nil
+	c.Add("b", make([]byte, 2))
+	if c.Add("b", make([]byte, 11)) || c.Contains("b") || c.Cost() != 7 {
+		t.Fatalf("b should be removed: cost %v", c.Cost())
+	}
+	if got, want := evicted, []string{"b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("evicted: got %v, want %v", got, want)
+	}
+
+	if evicted := c.Resize(5); evicted != 1 || c.Cost() != 1 {
+		t.Fatalf("resize should evict d: %v, cost %v", evicted, c.Cost())
+	}
+	c.Remove("a")
+	if c.Cost() != 0 || c.Len() != 0 {
+		t.Fatalf("bad cost %v or len %v", c.Cost(), c.Len())
+	}
+}
+
+func TestWeightedInvalidParams(t *testing.T) {
+	if _, err := NewWeighted[string, []byte](0, byteLen, nil); err == nil {
+		t.Fatalf("expected error for zero max cost")
+	}
+	if _, err := NewWeighted[string, []byte](1, nil, nil); err == nil {
+		t.Fatalf("expected error for nil cost function")
+	}
+}
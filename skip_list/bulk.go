@@ -0,0 +1,192 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-19 20:15:33
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-20 10:26:14
+ */
+package skip_list
+
+import (
+	"errors"
+	"iter"
+	"math/rand"
+)
+
+var ErrNotSorted = errors.New("keys are not in ascending order")
+
+// getLastElementNodes returns the last node on every level, and fills
+// prevRankCache with their ranks.
+func (list *SkipListG[K, V]) getLastElementNodes() []*elementNode[K, V] {
+	var prev *elementNode[K, V] = &list.elementNode
+	prevs := list.prevNodeCache
+	ranks := list.prevRankCache
+	rank := 0
+	for i := list.maxLevel - 1; i >= 0; i-- {
+		for next := prev.next[i]; next != nil; next = prev.next[i] {
+			rank += prev.span[i]
+			prev = &next.elementNode
+		}
+		prevs[i] = prev
+		ranks[i] = rank
+	}
+	return prevs
+}
+
+// BuildFromSorted appends the elements of seq, which must come in strictly
+// ascending key order after the keys already in the list. The level links
+// are set in a single pass, without searching for each key. It stops with
+// ErrNotSorted at the first key out of order, the elements before it are
+// kept.
+func (list *SkipListG[K, V]) BuildFromSorted(seq iter.Seq2[K, V]) error {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	lasts := list.getLastElementNodes()
+	ranks := list.prevRankCache
+	var err error
+	for key, value := range seq {
+		if list.tail != nil && list.compare(key, list.tail.key) <= 0 {
+			err = ErrNotSorted
+			break
+		}
+		list.appendElement(lasts, ranks, key, value, list.randLevel())
+	}
+	list.finishAppend(lasts, ranks)
+	return err
+}
+
+// appendElement links a new last element of the given level, lasts and ranks
+// are the last nodes on every level and their ranks.
+func (list *SkipListG[K, V]) appendElement(lasts []*elementNode[K, V], ranks []int, key K, value V, level int) {
+	element := &ElementG[K, V]{
+		elementNode: elementNode[K, V]{
+			next: make([]*ElementG[K, V], level),
+			span: make([]int, level),
+		},
+		prev:  list.tail,
+		key:   key,
+		value: value,
+	}
+	list.length++
+	for i := 0; i < level; i++ {
+		lasts[i].next[i] = element
+		lasts[i].span[i] = list.length - ranks[i]
+		lasts[i] = &element.elementNode
+		ranks[i] = list.length
+	}
+	list.tail = element
+}
+
+// finishAppend makes the nil links of the last nodes span up to the new end.
+func (list *SkipListG[K, V]) finishAppend(lasts []*elementNode[K, V], ranks []int) {
+	for i := range lasts {
+		lasts[i].span[i] = list.length - ranks[i]
+	}
+}
+
+// Split moves the elements with a key >= key into a new list, which is
+// returned, and keeps the smaller ones. It takes O(log n) expected time.
+func (list *SkipListG[K, V]) Split(key K) *SkipListG[K, V] {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	out := newSkipList[K, V](list.maxLevel, list.compare)
+	out.randSource = rand.NewSource(list.randSource.Int63())
+	out.SetProbability(list.probability)
+
+	prevs := list.getPrevElementNodes(key)
+	ranks := list.prevRankCache
+	kept := ranks[0]
+	first := prevs[0].next[0]
+	if first == nil {
+		return out
+	}
+	for i := range prevs {
+		out.next[i] = prevs[i].next[i]
+		out.span[i] = prevs[i].span[i] - (kept - ranks[i])
+		prevs[i].next[i] = nil
+		prevs[i].span[i] = kept - ranks[i]
+	}
+	out.length = list.length - kept
+	out.tail = list.tail
+	list.length = kept
+	list.tail = first.prev
+	first.prev = nil
+	return out
+}
+
+// Merge moves every element of other into the list, leaving other empty. If
+// all the keys of one list are smaller than those of the other and both have
+// the same max level, the lists are concatenated in O(log n) expected time.
+// Otherwise the elements of other are inserted one by one, replacing the
+// values of equal keys. Both lists must use the same order, and other must
+// not be merged into the list at the same time.
+func (list *SkipListG[K, V]) Merge(other *SkipListG[K, V]) {
+	if other == list {
+		return
+	}
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+	other.mutex.Lock()
+	defer other.mutex.Unlock()
+
+	if other.length == 0 {
+		return
+	}
+	if list.maxLevel == other.maxLevel {
+		if list.length == 0 || list.compare(list.tail.key, other.next[0].key) < 0 {
+			list.concat(other)
+			return
+		}
+		if list.compare(other.tail.key, list.next[0].key) < 0 {
+			other.concat(list)
+			list.takeElements(other)
+			return
+		}
+	}
+
+	for e := other.next[0]; e != nil; e = e.next[0] {
+		list.set(e.key, e.value)
+	}
+	other.clear()
+}
+
+// concat appends the elements of other, whose keys are all greater, and
+// empties it.
+func (list *SkipListG[K, V]) concat(other *SkipListG[K, V]) {
+	lasts := list.getLastElementNodes()
+	ranks := list.prevRankCache
+	total := list.length + other.length
+	for i := range lasts {
+		if next := other.next[i]; next != nil {
+			lasts[i].next[i] = next
+			lasts[i].span[i] = list.length - ranks[i] + other.span[i]
+		} else {
+			lasts[i].span[i] = total - ranks[i]
+		}
+	}
+	// the nil links inside other already span up to its end, which is also
+	// the end of the concatenation
+	if first := other.next[0]; first != nil {
+		first.prev = list.tail
+	}
+	list.tail = other.tail
+	list.length = total
+	other.clear()
+}
+
+// takeElements moves all the elements of other, leaving it empty.
+func (list *SkipListG[K, V]) takeElements(other *SkipListG[K, V]) {
+	copy(list.next, other.next)
+	copy(list.span, other.span)
+	list.tail = other.tail
+	list.length = other.length
+	other.clear()
+}
+
+func (list *SkipListG[K, V]) clear() {
+	clear(list.next)
+	clear(list.span)
+	list.tail = nil
+	list.length = 0
+}
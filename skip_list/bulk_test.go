@@ -0,0 +1,177 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-19 21:05:48
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-19 21:40:57
+ */
+package skip_list
+
+import (
+	"iter"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// checkList verifies spans, the prev links and the tail, and returns the keys.
+func checkList[K, V any](t *testing.T, list *SkipListG[K, V]) []K {
+	t.Helper()
+	checkSpans(t, list)
+	var keys []K
+	var prev *ElementG[K, V]
+	for e := list.next[0]; e != nil; e = e.next[0] {
+		if e.prev != prev {
+			t.Fatalf("bad prev of %v", e.key)
+		}
+		keys = append(keys, e.key)
+		prev = e
+	}
+	if list.tail != prev {
+		t.Fatalf("bad tail")
+	}
+	return keys
+}
+
+func pairs(keys ...int) iter.Seq2[int, int] {
+	return func(yield func(int, int) bool) {
+		for _, k := range keys {
+			if !yield(k, -k) {
+				return
+			}
+		}
+	}
+}
+
+func TestSkipListBuildFromSorted(t *testing.T) {
+	lis := NewOrdered[int, int]()
+	if err := lis.BuildFromSorted(pairs()); err != nil {
+		t.Fatal(err)
+	}
+	var want []int
+	for i := 0; i < 1000; i++ {
+		want = append(want, i*2)
+	}
+	if err := lis.BuildFromSorted(pairs(want[:500]...)); err != nil {
+		t.Fatal(err)
+	}
+	if err := lis.BuildFromSorted(pairs(want[500:]...)); err != nil {
+		t.Fatal(err)
+	}
+	if got := checkList(t, lis); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v keys", len(got))
+	}
+	if e := lis.Get(1998); e == nil || e.Value() != -1998 {
+		t.Fatalf("1998 should be set")
+	}
+	if r := lis.Rank(1000); r != 500 {
+		t.Fatalf("rank of 1000: %v", r)
+	}
+
+	if err := lis.BuildFromSorted(pairs(1998)); err != ErrNotSorted {
+		t.Fatalf("expected ErrNotSorted, got %v", err)
+	}
+	lis.Set(1, 1)
+	lis.Remove(1000)
+	checkList(t, lis)
+}
+
+func TestSkipListBuildFromSortedStops(t *testing.T) {
+	lis := NewOrdered[int, int]()
+	if err := lis.BuildFromSorted(pairs(1, 3, 2, 4)); err != ErrNotSorted {
+		t.Fatalf("expected ErrNotSorted, got %v", err)
+	}
+	if got := checkList(t, lis); !reflect.DeepEqual(got, []int{1, 3}) {
+		t.Fatalf("got %v", got)
+	}
+	lis.Set(2, 2)
+	if got := checkList(t, lis); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestSkipListSplit(t *testing.T) {
+	for _, at := range []int{-1, 0, 1, 250, 251, 999, 2000} {
+		lis := NewOrdered[int, int]()
+		for i := 0; i < 1000; i += 2 {
+			lis.Set(i, i)
+		}
+		right := lis.Split(at)
+		left := checkList(t, lis)
+		rest := checkList(t, right)
+		if len(left)+len(rest) != 500 {
+			t.Fatalf("split at %v lost keys: %v + %v", at, len(left), len(rest))
+		}
+		if len(left) > 0 && left[len(left)-1] >= at {
+			t.Fatalf("split at %v kept %v", at, left[len(left)-1])
+		}
+		if len(rest) > 0 && rest[0] < at {
+			t.Fatalf("split at %v moved %v", at, rest[0])
+		}
+		// both halves stay usable
+		lis.Set(at-1, 0)
+		right.Set(at, 0)
+		right.Remove(998)
+		checkList(t, lis)
+		checkList(t, right)
+	}
+}
+
+func TestSkipListMerge(t *testing.T) {
+	build := func(from, to int) *SkipListG[int, int] {
+		lis := NewOrdered[int, int]()
+		for i := from; i < to; i++ {
+			lis.Set(i, i)
+		}
+		return lis
+	}
+	span := func(from, to int) (keys []int) {
+		for i := from; i < to; i++ {
+			keys = append(keys, i)
+		}
+		return keys
+	}
+
+	cases := []struct {
+		name        string
+		left, right *SkipListG[int, int]
+		want        []int
+	}{
+		{"after", build(0, 300), build(300, 700), span(0, 700)},
+		{"before", build(300, 700), build(0, 300), span(0, 700)},
+		{"into empty", build(0, 0), build(0, 100), span(0, 100)},
+		{"empty", build(0, 100), build(0, 0), span(0, 100)},
+		{"overlap", build(0, 400), build(200, 700), span(0, 700)},
+	}
+	for _, c := range cases {
+		c.left.Merge(c.right)
+		if got := checkList(t, c.left); !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("%v: got %v keys", c.name, len(got))
+		}
+		if c.right.Len() != 0 || checkList(t, c.right) != nil {
+			t.Fatalf("%v: merged list should be empty", c.name)
+		}
+		c.right.Set(1, 1)
+		checkList(t, c.right)
+	}
+}
+
+func TestSkipListSplitMerge(t *testing.T) {
+	lis := NewOrdered[int, int]()
+	for i := 0; i < 2000; i++ {
+		lis.Set(rand.Intn(5000), i)
+	}
+	want := checkList(t, lis)
+	for i := 0; i < 100; i++ {
+		at := rand.Intn(5000)
+		right := lis.Split(at)
+		if rand.Intn(2) == 0 {
+			lis.Merge(right)
+		} else {
+			right.Merge(lis)
+			lis = right
+		}
+		if got := checkList(t, lis); !reflect.DeepEqual(got, want) {
+			t.Fatalf("keys changed after split at %v", at)
+		}
+	}
+}
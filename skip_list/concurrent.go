@@ -0,0 +1,239 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-19 17:05:39
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-19 18:12:55
+ */
+package skip_list
+
+import (
+	"cmp"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+const concurrentMaxLevel = 32
+
+type lazyNode[K, V any] struct {
+	key         K
+	value       V
+	next        []atomic.Pointer[lazyNode[K, V]]
+	mutex       sync.Mutex
+	marked      atomic.Bool
+	fullyLinked atomic.Bool
+}
+
+func (n *lazyNode[K, V]) topLevel() int {
+	return len(n.next)
+}
+
+// ConcurrentSkipList is Herlihy's lazy skip list. Insert and Delete only lock
+// the predecessors of the key, a node is logically deleted by marking it
+// before it is unlinked, and Contains takes no lock at all. Every operation
+// is linearizable: Insert when the new node is fully linked, Delete when the
+// node is marked.
+type ConcurrentSkipList[K, V any] struct {
+	head     lazyNode[K, V]
+	compare  func(a, b K) int
+	length   atomic.Int64
+	maxLevel int
+}
+
+func NewConcurrent[K cmp.Ordered, V any]() *ConcurrentSkipList[K, V] {
+	return NewConcurrentWithComparator[K, V](cmp.Compare[K])
+}
+
+func NewConcurrentWithComparator[K, V any](compare func(a, b K) int) *ConcurrentSkipList[K, V] {
+	if compare == nil {
+		panic("nil comparator")
+	}
+	list := &ConcurrentSkipList[K, V]{
+		compare:  compare,
+		maxLevel: concurrentMaxLevel,
+	}
+	list.head.next = make([]atomic.Pointer[lazyNode[K, V]], concurrentMaxLevel)
+	return list
+}
+
+func (list *ConcurrentSkipList[K, V]) randLevel() int {
+	level := 1
+	for level < list.maxLevel && rand.Float64() < DefaultProbability {
+		level++
+	}
+	return level
+}
+
+// find fills preds and succs with the nodes around key on every level and
+// returns the highest level where key was found, -1 if it was not. A nil
+// successor stands for the end of the list.
+func (list *ConcurrentSkipList[K, V]) find(key K, preds, succs []*lazyNode[K, V]) int {
+	found := -1
+	pred := &list.head
+	for level := list.maxLevel - 1; level >= 0; level-- {
+		curr := pred.next[level].Load()
+		for curr != nil && list.compare(key, curr.key) > 0 {
+			pred = curr
+			curr = pred.next[level].Load()
+		}
+		if found == -1 && curr != nil && list.compare(key, curr.key) == 0 {
+			found = level
+		}
+		preds[level] = pred
+		succs[level] = curr
+	}
+	return found
+}
+
+// lockPreds locks the distinct predecessors of the first levels and checks
+// they are still linked to their successor. It returns the highest locked
+// level, to be passed to unlockPreds, and whether the check passed.
+func (list *ConcurrentSkipList[K, V]) lockPreds(levels int, preds, succs []*lazyNode[K, V], victim *lazyNode[K, V]) (int, bool) {
+	highestLocked := -1
+	var prevPred *lazyNode[K, V]
+	valid := true
+	for level := 0; valid && level < levels; level++ {
+		pred, succ := preds[level], succs[level]
+		if victim != nil {
+			succ = victim
+		}
+		if pred != prevPred {
+			pred.mutex.Lock()
+			highestLocked = level
+			prevPred = pred
+		}
+		valid = !pred.marked.Load() && pred.next[level].Load() == succ
+		if victim == nil && succ != nil {
+			valid = valid && !succ.marked.Load()
+		}
+	}
+	return highestLocked, valid
+}
+
+func (list *ConcurrentSkipList[K, V]) unlockPreds(highestLocked int, preds []*lazyNode[K, V]) {
+	var prevPred *lazyNode[K, V]
+	for level := 0; level <= highestLocked; level++ {
+		if preds[level] != prevPred {
+			preds[level].mutex.Unlock()
+			prevPred = preds[level]
+		}
+	}
+}
+
+// Insert adds key with value, returns false if key is already in the list.
+func (list *ConcurrentSkipList[K, V]) Insert(key K, value V) bool {
+	topLevel := list.randLevel()
+	var preds, succs [concurrentMaxLevel]*lazyNode[K, V]
+	for {
+		if found := list.find(key, preds[:], succs[:]); found != -1 {
+			node := succs[found]
+			if !node.marked.Load() {
+				// wait for a concurrent insert to finish linking
+				for !node.fullyLinked.Load() {
+					runtime.Gosched()
+				}
+				return false
+			}
+			// being deleted, retry once it is unlinked
+			continue
+		}
+
+		highestLocked, valid := list.lockPreds(topLevel, preds[:], succs[:], nil)
+		if !valid {
+			list.unlockPreds(highestLocked, preds[:])
+			continue
+		}
+
+		node := &lazyNode[K, V]{
+			key:   key,
+			value: value,
+			next:  make([]atomic.Pointer[lazyNode[K, V]], topLevel),
+		}
+		for level := 0; level < topLevel; level++ {
+			node.next[level].Store(succs[level])
+		}
+		for level := 0; level < topLevel; level++ {
+			preds[level].next[level].Store(node)
+		}
+		node.fullyLinked.Store(true)
+		list.length.Add(1)
+		list.unlockPreds(highestLocked, preds[:])
+		return true
+	}
+}
+
+// Delete removes key, returns false if it was not in the list.
+func (list *ConcurrentSkipList[K, V]) Delete(key K) bool {
+	var victim *lazyNode[K, V]
+	isMarked := false
+	var preds, succs [concurrentMaxLevel]*lazyNode[K, V]
+	for {
+		found := list.find(key, preds[:], succs[:])
+		if !isMarked {
+			if found == -1 {
+				return false
+			}
+			victim = succs[found]
+			if !victim.fullyLinked.Load() || victim.topLevel()-1 != found || victim.marked.Load() {
+				return false
+			}
+			victim.mutex.Lock()
+			if victim.marked.Load() {
+				victim.mutex.Unlock()
+				return false
+			}
+			victim.marked.Store(true)
+			isMarked = true
+		}
+
+		highestLocked, valid := list.lockPreds(victim.topLevel(), preds[:], succs[:], victim)
+		if !valid {
+			list.unlockPreds(highestLocked, preds[:])
+			continue
+		}
+		for level := victim.topLevel() - 1; level >= 0; level-- {
+			preds[level].next[level].Store(victim.next[level].Load())
+		}
+		victim.mutex.Unlock()
+		list.length.Add(-1)
+		list.unlockPreds(highestLocked, preds[:])
+		return true
+	}
+}
+
+// Contains is wait free, it never takes a lock.
+func (list *ConcurrentSkipList[K, V]) Contains(key K) bool {
+	_, ok := list.Get(key)
+	return ok
+}
+
+func (list *ConcurrentSkipList[K, V]) Get(key K) (value V, ok bool) {
+	var preds, succs [concurrentMaxLevel]*lazyNode[K, V]
+	found := list.find(key, preds[:], succs[:])
+	if found == -1 {
+		return
+	}
+	node := succs[found]
+	if !node.fullyLinked.Load() || node.marked.Load() {
+		return
+	}
+	return node.value, true
+}
+
+func (list *ConcurrentSkipList[K, V]) Len() int {
+	return int(list.length.Load())
+}
+
+// Range calls fn for every key in ascending order, until fn returns false.
+// It is weakly consistent: keys inserted or deleted meanwhile may or may not
+// be seen.
+func (list *ConcurrentSkipList[K, V]) Range(fn func(key K, value V) bool) {
+	for node := list.head.next[0].Load(); node != nil; node = node.next[0].Load() {
+		if node.fullyLinked.Load() && !node.marked.Load() {
+			if !fn(node.key, node.value) {
+				return
+			}
+		}
+	}
+}
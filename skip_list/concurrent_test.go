@@ -0,0 +1,164 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-19 17:48:02
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-19 18:12:55
+ */
+package skip_list
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentSkipList(t *testing.T) {
+	lis := NewConcurrent[int, string]()
+	if !lis.Insert(2, "b") || !lis.Insert(1, "a") || !lis.Insert(3, "c") {
+		t.Fatal("new keys should be inserted")
+	}
+	if lis.Insert(2, "x") {
+		t.Fatal("duplicate keys should not be inserted")
+	}
+	if v, ok := lis.Get(2); !ok || v != "b" {
+		t.Fatalf("bad value for 2: %v", v)
+	}
+	if !lis.Delete(2) || lis.Delete(2) || lis.Contains(2) {
+		t.Fatal("2 should be deleted once")
+	}
+	var keys []int
+	lis.Range(func(k int, v string) bool {
+		keys = append(keys, k)
+		return true
+	})
+	if len(keys) != 2 || keys[0] != 1 || keys[1] != 3 || lis.Len() != 2 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+}
+
+func TestConcurrentSkipListParallel(t *testing.T) {
+	lis := NewConcurrent[int, int]()
+	const workers, keys = 8, 256
+	// every worker owns the keys equal to its id modulo workers, and checks
+	// them against its own map while the others hammer the shared levels
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(int64(w)))
+			own := map[int]bool{}
+			for i := 0; i < 5000; i++ {
+				k := r.Intn(keys/workers)*workers + w
+				switch r.Intn(3) {
+				case 0:
+					if lis.Insert(k, k) == own[k] {
+						t.Errorf("insert %v disagrees with %v", k, own[k])
+						return
+					}
+					own[k] = true
+				case 1:
+					if lis.Delete(k) != own[k] {
+						t.Errorf("delete %v disagrees with %v", k, own[k])
+						return
+					}
+					own[k] = false
+				case 2:
+					if lis.Contains(k) != own[k] {
+						t.Errorf("contains %v disagrees with %v", k, own[k])
+						return
+					}
+				}
+				lis.Contains(r.Intn(keys))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	prev, n := -1, 0
+	lis.Range(func(k int, v int) bool {
+		if k <= prev {
+			t.Fatalf("keys out of order: %v after %v", k, prev)
+		}
+		prev = k
+		n++
+		return true
+	})
+	if n != lis.Len() {
+		t.Fatalf("len %v, counted %v", lis.Len(), n)
+	}
+}
+
+// Only one of the racing inserts and deletes of a key can win.
+func TestConcurrentSkipListContended(t *testing.T) {
+	lis := NewConcurrent[int, int]()
+	for round := 0; round < 100; round++ {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		inserted, deleted := 0, 0
+		for g := 0; g < 8; g++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				if lis.Insert(round, g) {
+					mu.Lock()
+					inserted++
+					mu.Unlock()
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				if lis.Delete(round) {
+					mu.Lock()
+					deleted++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		present := 0
+		if lis.Contains(round) {
+			present = 1
+		}
+		if inserted-deleted != present {
+			t.Fatalf("round %v: %v inserts, %v deletes, present %v", round, inserted, deleted, present)
+		}
+	}
+}
+
+func benchmarkMixed(b *testing.B, insert func(k int), remove func(k int), contains func(k int)) {
+	for i := 0; i < 1<<14; i += 2 {
+		insert(i)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		for pb.Next() {
+			k := r.Intn(1 << 14)
+			switch n := r.Intn(10); {
+			case n == 0:
+				insert(k)
+			case n == 1:
+				remove(k)
+			default:
+				contains(k)
+			}
+		}
+	})
+}
+
+func BenchmarkConcurrentSkipList(b *testing.B) {
+	lis := NewConcurrent[int, int]()
+	benchmarkMixed(b,
+		func(k int) { lis.Insert(k, k) },
+		func(k int) { lis.Delete(k) },
+		func(k int) { lis.Contains(k) })
+}
+
+func BenchmarkMutexSkipList(b *testing.B) {
+	lis := NewOrdered[int, int]()
+	benchmarkMixed(b,
+		func(k int) { lis.Set(k, k) },
+		func(k int) { lis.Remove(k) },
+		func(k int) { lis.Get(k) })
+}
@@ -0,0 +1,85 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-19 19:02:14
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-19 19:36:20
+ */
+package skip_list
+
+import (
+	"cmp"
+	"errors"
+	"math/rand"
+)
+
+type options struct {
+	maxLevel    int
+	probability float64
+	randSource  rand.Source
+}
+
+// Option configures a skip list built by NewWithOptions.
+type Option func(*options)
+
+// WithMaxLevel sets the number of levels, from 1 to MaxLevelLimit. A list
+// with probability p stays efficient up to about (1/p)^maxLevel elements.
+func WithMaxLevel(maxLevel int) Option {
+	return func(o *options) {
+		o.maxLevel = maxLevel
+	}
+}
+
+// WithProbability sets the chance, in (0, 1), for an element to reach the
+// next level.
+func WithProbability(probability float64) Option {
+	return func(o *options) {
+		o.probability = probability
+	}
+}
+
+// WithRandSource sets the source of the element levels. The source is only
+// used under the write lock, so it does not need to be safe for concurrent
+// use. The same source and the same operations build the same list.
+func WithRandSource(src rand.Source) Option {
+	return func(o *options) {
+		o.randSource = src
+	}
+}
+
+// WithSeed is WithRandSource(rand.NewSource(seed)).
+func WithSeed(seed int64) Option {
+	return WithRandSource(rand.NewSource(seed))
+}
+
+// NewWithOptions creates a skip list ordered by the natural order of K.
+func NewWithOptions[K cmp.Ordered, V any](opts ...Option) (*SkipListG[K, V], error) {
+	return NewWithComparatorOptions[K, V](cmp.Compare[K], opts...)
+}
+
+// NewWithComparatorOptions creates a skip list ordered by compare, the
+// options are validated before anything is allocated.
+func NewWithComparatorOptions[K, V any](compare func(a, b K) int, opts ...Option) (*SkipListG[K, V], error) {
+	o := options{
+		maxLevel:    DefaultMaxlevel,
+		probability: DefaultProbability,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if compare == nil {
+		return nil, errors.New("nil comparator")
+	}
+	if o.maxLevel < 1 || o.maxLevel > MaxLevelLimit {
+		return nil, errors.New("invalid maxlevel")
+	}
+	if !(o.probability > 0 && o.probability < 1) {
+		return nil, errors.New("probability must be in (0, 1)")
+	}
+
+	list := newSkipList[K, V](o.maxLevel, compare)
+	if o.randSource != nil {
+		list.randSource = o.randSource
+	}
+	list.SetProbability(o.probability)
+	return list, nil
+}
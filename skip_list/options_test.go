@@ -0,0 +1,75 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-19 19:24:48
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-19 19:36:20
+ */
+package skip_list
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func levels[K, V any](list *SkipListG[K, V]) (out []int) {
+	for e := list.Front(); e != nil; e = e.Next() {
+		out = append(out, len(e.next))
+	}
+	return
+}
+
+func TestSkipListSeed(t *testing.T) {
+	build := func() *SkipListG[int, int] {
+		list, err := NewWithOptions[int, int](WithSeed(42), WithMaxLevel(32), WithProbability(0.25))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		for _, k := range rand.New(rand.NewSource(1)).Perm(1000) {
+			list.Set(k, k)
+		}
+		return list
+	}
+	a, b := build(), build()
+	if !reflect.DeepEqual(levels(a), levels(b)) {
+		t.Fatal("the same seed should build the same structure")
+	}
+	checkSpans(t, a)
+}
+
+func TestSkipListMaxLevel64(t *testing.T) {
+	list, err := NewWithOptions[int, int](WithMaxLevel(MaxLevelLimit), WithProbability(0.5))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 100000; i++ {
+		list.Set(i, i)
+	}
+	if list.Len() != 100000 || list.GetByRank(54321).Key() != 54321 {
+		t.Fatal("bad list")
+	}
+	top := 0
+	for _, l := range levels(list) {
+		top = max(top, l)
+	}
+	if top <= DefaultMaxlevel {
+		t.Fatalf("elements should use more than %v levels: %v", DefaultMaxlevel, top)
+	}
+}
+
+func TestSkipListInvalidOptions(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithMaxLevel(0)},
+		{WithMaxLevel(MaxLevelLimit + 1)},
+		{WithProbability(0)},
+		{WithProbability(1)},
+		{WithProbability(-0.5)},
+	} {
+		if _, err := NewWithOptions[int, int](opts...); err == nil {
+			t.Fatalf("expected error for %v", opts)
+		}
+	}
+	if _, err := NewWithComparatorOptions[int, int](nil); err == nil {
+		t.Fatal("expected error for a nil comparator")
+	}
+}
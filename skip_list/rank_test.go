@@ -0,0 +1,108 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-19 14:20:17
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-19 14:48:30
+ */
+package skip_list
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// checkSpans verifies every span against the level 0 positions.
+func checkSpans[K, V any](t *testing.T, list *SkipListG[K, V]) {
+	t.Helper()
+	pos := map[*elementNode[K, V]]int{&list.elementNode: 0}
+	n := 0
+	for e := list.next[0]; e != nil; e = e.next[0] {
+		n++
+		pos[&e.elementNode] = n
+	}
+	if n != list.length {
+		t.Fatalf("length %v, counted %v", list.length, n)
+	}
+	for node, rank := range pos {
+		for i, next := range node.next {
+			want := list.length - rank
+			if next != nil {
+				want = pos[&next.elementNode] - rank
+			}
+			if node.span[i] != want {
+				t.Fatalf("span of rank %v level %v: got %v, want %v", rank, i, node.span[i], want)
+			}
+		}
+	}
+}
+
+func TestSkipListRank(t *testing.T) {
+	lis := NewOrdered[int, int]()
+	var keys []int
+	for i := 0; i < 2000; i++ {
+		k := rand.Intn(1000)
+		if rand.Intn(3) == 0 {
+			lis.Remove(k)
+		} else {
+			lis.Set(k, k)
+		}
+	}
+	checkSpans(t, lis)
+	for e := lis.Front(); e != nil; e = e.Next() {
+		keys = append(keys, e.Key())
+	}
+
+	for i, k := range keys {
+		if r := lis.Rank(k); r != i {
+			t.Fatalf("rank of %v: got %v, want %v", k, r, i)
+		}
+		if e := lis.GetByRank(i); e == nil || e.Key() != k {
+			t.Fatalf("element at %v: got %v, want %v", i, e, k)
+		}
+	}
+	if lis.GetByRank(-1) != nil || lis.GetByRank(len(keys)) != nil {
+		t.Fatal("out of range ranks should be nil")
+	}
+	if r := lis.Rank(1000); r != -1 {
+		t.Fatalf("missing key should rank -1: %v", r)
+	}
+
+	for i := 0; i < 100; i++ {
+		lo, hi := rand.Intn(1100)-50, rand.Intn(1100)-50
+		want := sort.SearchInts(keys, hi+1) - sort.SearchInts(keys, lo)
+		if lo > hi {
+			want = 0
+		}
+		if got := lis.CountInRange(lo, hi); got != want {
+			t.Fatalf("count in [%v, %v]: got %v, want %v", lo, hi, got, want)
+		}
+	}
+}
+
+func TestSkipListRangeByRank(t *testing.T) {
+	lis := NewOrdered[int, string]()
+	for _, k := range []int{50, 10, 40, 20, 30} {
+		lis.Set(k, "")
+	}
+	collect := func(start, stop int) (out []int) {
+		lis.RangeByRank(start, stop, func(e *ElementG[int, string]) bool {
+			out = append(out, e.Key())
+			return true
+		})
+		return
+	}
+	if got, want := collect(1, 3), []int{20, 30, 40}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("range: got %v, want %v", got, want)
+	}
+	if got, want := collect(-2, -1), []int{40, 50}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("range: got %v, want %v", got, want)
+	}
+	if got, want := collect(-100, 100), []int{10, 20, 30, 40, 50}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("range: got %v, want %v", got, want)
+	}
+	if got := collect(3, 1); got != nil {
+		t.Fatalf("empty range: got %v", got)
+	}
+}
@@ -2,13 +2,14 @@
  * @Author: zengzh
  * @Date: 2022-12-28 15:38:33
  * @Last Modified by: zengzh
- * @Last Modified time: 2022-12-29 14:10:11
+ * @Last Modified time: 2026-10-19 21:40:57
  */
 
 //  https://studygolang.com/articles/22491
 package skip_list
 
 import (
+	"cmp"
 	"math"
 	"math/rand"
 	"sync"
@@ -18,41 +19,88 @@ import (
 const (
 	DefaultMaxlevel    int     = 10
 	DefaultProbability float64 = 1 / math.E
+	// MaxLevelLimit is the highest max level a skip list accepts.
+	MaxLevelLimit int = 64
 )
 
-type elementNode struct {
-	next []*Element
+// elementNode holds the forward links of a node. span[i] is the number of
+// elements passed by following next[i], counting the target, so ranks can be
+// summed up while searching. A nil link spans up to the last element.
+type elementNode[K, V any] struct {
+	next []*ElementG[K, V]
+	span []int
 }
 
-type Element struct {
-	elementNode
-	key   float64
-	value interface{}
+// ElementG is an element of a SkipListG.
+type ElementG[K, V any] struct {
+	elementNode[K, V]
+	prev  *ElementG[K, V]
+	key   K
+	value V
 }
 
-func (e *Element) Key() float64 {
+func (e *ElementG[K, V]) Key() K {
 	return e.key
 }
 
-func (e *Element) Value() interface{} {
+func (e *ElementG[K, V]) Value() V {
 	return e.value
 }
 
-type SkipList struct {
-	elementNode
+// Next returns the next element in key order or nil. Walking the list is not
+// synchronized with writers, use Range for that.
+func (e *ElementG[K, V]) Next() *ElementG[K, V] {
+	return e.next[0]
+}
+
+// Prev returns the previous element in key order or nil.
+func (e *ElementG[K, V]) Prev() *ElementG[K, V] {
+	return e.prev
+}
+
+// SkipListG is a skip list ordered by a comparator, which returns a negative
+// number, zero or a positive number when a < b, a == b or a > b.
+type SkipListG[K, V any] struct {
+	elementNode[K, V]
+	tail          *ElementG[K, V]
+	compare       func(a, b K) int
 	maxLevel      int
 	length        int
 	randSource    rand.Source
 	probability   float64
 	probTabale    []float64
 	mutex         sync.RWMutex
-	prevNodeCache []*elementNode
+	prevNodeCache []*elementNode[K, V]
+	prevRankCache []int
 }
 
+// SkipList and Element are the original float64 keyed skip list.
+type (
+	SkipList = SkipListG[float64, interface{}]
+	Element  = ElementG[float64, interface{}]
+)
+
 func NewSkipList() *SkipList {
 	return NewWithMaxLevel(DefaultMaxlevel)
 }
 
+func NewWithMaxLevel(maxLevel int) *SkipList {
+	return newSkipList[float64, interface{}](maxLevel, cmp.Compare[float64])
+}
+
+// NewOrdered creates a skip list ordered by the natural order of K.
+func NewOrdered[K cmp.Ordered, V any]() *SkipListG[K, V] {
+	return newSkipList[K, V](DefaultMaxlevel, cmp.Compare[K])
+}
+
+// NewWithComparator creates a skip list ordered by compare.
+func NewWithComparator[K, V any](compare func(a, b K) int) *SkipListG[K, V] {
+	if compare == nil {
+		panic("nil comparator")
+	}
+	return newSkipList[K, V](DefaultMaxlevel, compare)
+}
+
 func ProbabilityTable(probability float64, maxlevel int) (table []float64) {
 	for i := 1; i <= maxlevel; i++ {
 		prob := math.Pow(probability, float64(i-1))
@@ -61,14 +109,19 @@ func ProbabilityTable(probability float64, maxlevel int) (table []float64) {
 	return table
 }
 
-func NewWithMaxLevel(maxLevel int) *SkipList {
-	if maxLevel < 1 || maxLevel > DefaultMaxlevel {
+func newSkipList[K, V any](maxLevel int, compare func(a, b K) int) *SkipListG[K, V] {
+	if maxLevel < 1 || maxLevel > MaxLevelLimit {
 		panic("invalid maxlevel")
 	}
 
-	return &SkipList{
-		elementNode:   elementNode{next: make([]*Element, maxLevel)},
-		prevNodeCache: make([]*elementNode, maxLevel),
+	return &SkipListG[K, V]{
+		elementNode: elementNode[K, V]{
+			next: make([]*ElementG[K, V], maxLevel),
+			span: make([]int, maxLevel),
+		},
+		prevNodeCache: make([]*elementNode[K, V], maxLevel),
+		prevRankCache: make([]int, maxLevel),
+		compare:       compare,
 		maxLevel:      maxLevel,
 		randSource:    rand.New(rand.NewSource((time.Now().UnixNano()))),
 		probability:   DefaultProbability,
@@ -76,7 +129,7 @@ func NewWithMaxLevel(maxLevel int) *SkipList {
 	}
 }
 
-func (list *SkipList) randLevel() (level int) {
+func (list *SkipListG[K, V]) randLevel() (level int) {
 	r := float64(list.randSource.Int63()) / (1 << 63)
 	level = 1
 	for level < list.maxLevel && r < list.probTabale[level] {
@@ -85,64 +138,85 @@ func (list *SkipList) randLevel() (level int) {
 	return level
 }
 
-func (list *SkipList) SetProbability(newProbability float64) {
+func (list *SkipListG[K, V]) SetProbability(newProbability float64) {
 	list.probability = newProbability
 	list.probTabale = ProbabilityTable(newProbability, list.maxLevel)
 }
 
-func (list *SkipList) Set(key float64, value interface{}) *Element {
+func (list *SkipListG[K, V]) Set(key K, value V) *ElementG[K, V] {
 	list.mutex.Lock()
 	defer list.mutex.Unlock()
+	return list.set(key, value)
+}
 
-	var element *Element
+func (list *SkipListG[K, V]) set(key K, value V) *ElementG[K, V] {
+	var element *ElementG[K, V]
 	prevs := list.getPrevElementNodes(key)
-	if element = prevs[0].next[0]; element != nil && key == element.key {
+	if element = prevs[0].next[0]; element != nil && list.compare(key, element.key) == 0 {
 		element.value = value
 		return element
 	}
 
-	element = &Element{
-		elementNode: elementNode{next: make([]*Element, list.randLevel())},
-		key:         key,
-		value:       value,
+	level := list.randLevel()
+	element = &ElementG[K, V]{
+		elementNode: elementNode[K, V]{
+			next: make([]*ElementG[K, V], level),
+			span: make([]int, level),
+		},
+		key:   key,
+		value: value,
 	}
 	list.length++
 
+	ranks := list.prevRankCache
 	for i := range element.next {
 		element.next[i] = prevs[i].next[i]
 		prevs[i].next[i] = element
+		element.span[i] = prevs[i].span[i] - (ranks[0] - ranks[i])
+		prevs[i].span[i] = ranks[0] - ranks[i] + 1
+	}
+	for i := level; i < list.maxLevel; i++ {
+		prevs[i].span[i]++
+	}
+	if next := element.next[0]; next != nil {
+		element.prev = next.prev
+		next.prev = element
+	} else {
+		element.prev = list.tail
+		list.tail = element
 	}
 	return element
 }
 
-func (list *SkipList) Get(key float64) *Element {
-	list.mutex.Lock()
-	defer list.mutex.Unlock()
+// Get only reads the list, so concurrent lookups share the read lock.
+func (list *SkipListG[K, V]) Get(key K) *ElementG[K, V] {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
 
-	var prev *elementNode = &list.elementNode
-	var next *Element
-	for i := list.maxLevel - 1; i >= 0; i-- {
-		next = prev.next[i]
-		for next != nil && key > next.key {
-			prev = &next.elementNode
-			next = next.next[i]
-		}
-	}
-	if next != nil && next.key == key {
+	if next := list.seek(key); next != nil && list.compare(next.key, key) == 0 {
 		return next
 	}
 	return nil
 }
 
-func (list *SkipList) Remove(key float64) *Element {
+func (list *SkipListG[K, V]) Remove(key K) *ElementG[K, V] {
 	list.mutex.Lock()
 	defer list.mutex.Unlock()
 
-	var element *Element
+	var element *ElementG[K, V]
 	prevs := list.getPrevElementNodes(key)
-	if element = prevs[0].next[0]; element != nil && key == element.key {
+	if element = prevs[0].next[0]; element != nil && list.compare(key, element.key) == 0 {
 		for k, v := range element.next {
 			prevs[k].next[k] = v
+			prevs[k].span[k] += element.span[k] - 1
+		}
+		for k := len(element.next); k < list.maxLevel; k++ {
+			prevs[k].span[k]--
+		}
+		if next := element.next[0]; next != nil {
+			next.prev = element.prev
+		} else {
+			list.tail = element.prev
 		}
 		list.length--
 		return element
@@ -150,17 +224,182 @@ func (list *SkipList) Remove(key float64) *Element {
 	return nil
 }
 
-func (list *SkipList) getPrevElementNodes(key float64) []*elementNode {
-	var prev *elementNode = &list.elementNode
-	var next *Element
+func (list *SkipListG[K, V]) Len() int {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+	return list.length
+}
+
+// Front returns the element with the smallest key or nil.
+func (list *SkipListG[K, V]) Front() *ElementG[K, V] {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+	return list.next[0]
+}
+
+// Back returns the element with the largest key or nil.
+func (list *SkipListG[K, V]) Back() *ElementG[K, V] {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+	return list.tail
+}
+
+// Seek returns the first element whose key is >= key or nil.
+func (list *SkipListG[K, V]) Seek(key K) *ElementG[K, V] {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+	return list.seek(key)
+}
+
+func (list *SkipListG[K, V]) seek(key K) *ElementG[K, V] {
+	var prev *elementNode[K, V] = &list.elementNode
+	var next *ElementG[K, V]
+	for i := list.maxLevel - 1; i >= 0; i-- {
+		next = prev.next[i]
+		for next != nil && list.compare(key, next.key) > 0 {
+			prev = &next.elementNode
+			next = next.next[i]
+		}
+	}
+	return next
+}
+
+// Range calls fn for every element with from <= key < to in ascending order,
+// until fn returns false. The list is read locked meanwhile, so fn must not
+// modify it.
+func (list *SkipListG[K, V]) Range(from, to K, fn func(e *ElementG[K, V]) bool) {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+	for e := list.seek(from); e != nil && list.compare(e.key, to) < 0; e = e.next[0] {
+		if !fn(e) {
+			return
+		}
+	}
+}
+
+// ReverseRange calls fn for every element with to < key <= from in
+// descending order, until fn returns false.
+func (list *SkipListG[K, V]) ReverseRange(from, to K, fn func(e *ElementG[K, V]) bool) {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+	e := list.seek(from)
+	if e == nil {
+		e = list.tail
+	} else if list.compare(e.key, from) > 0 {
+		e = e.prev
+	}
+	for ; e != nil && list.compare(e.key, to) > 0; e = e.prev {
+		if !fn(e) {
+			return
+		}
+	}
+}
+
+// getPrevElementNodes returns the last node before key on every level, and
+// fills prevRankCache with the rank of each of them, 0 for the head.
+func (list *SkipListG[K, V]) getPrevElementNodes(key K) []*elementNode[K, V] {
+	var prev *elementNode[K, V] = &list.elementNode
+	var next *ElementG[K, V]
 	prevs := list.prevNodeCache
+	ranks := list.prevRankCache
+	rank := 0
 	for i := list.maxLevel - 1; i >= 0; i-- {
 		next = prev.next[i]
-		for next != nil && key > next.key {
+		for next != nil && list.compare(key, next.key) > 0 {
+			rank += prev.span[i]
 			prev = &next.elementNode
 			next = prev.next[i]
 		}
 		prevs[i] = prev
+		ranks[i] = rank
 	}
 	return prevs
 }
+
+// countBefore returns the number of elements with a key < key, or <= key if
+// inclusive.
+func (list *SkipListG[K, V]) countBefore(key K, inclusive bool) int {
+	var prev *elementNode[K, V] = &list.elementNode
+	rank := 0
+	for i := list.maxLevel - 1; i >= 0; i-- {
+		for next := prev.next[i]; next != nil; next = prev.next[i] {
+			c := list.compare(next.key, key)
+			if c > 0 || (c == 0 && !inclusive) {
+				break
+			}
+			rank += prev.span[i]
+			prev = &next.elementNode
+		}
+	}
+	return rank
+}
+
+// Rank returns the 0 based position of key in the list, or -1 if it is not
+// in the list.
+func (list *SkipListG[K, V]) Rank(key K) int {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+	rank := list.countBefore(key, true)
+	if e := list.getByRank(rank - 1); e != nil && list.compare(e.key, key) == 0 {
+		return rank - 1
+	}
+	return -1
+}
+
+// GetByRank returns the element at the 0 based position rank or nil.
+func (list *SkipListG[K, V]) GetByRank(rank int) *ElementG[K, V] {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+	return list.getByRank(rank)
+}
+
+func (list *SkipListG[K, V]) getByRank(rank int) *ElementG[K, V] {
+	if rank < 0 || rank >= list.length {
+		return nil
+	}
+	var prev *elementNode[K, V] = &list.elementNode
+	var next *ElementG[K, V]
+	traversed := 0
+	for i := list.maxLevel - 1; i >= 0; i-- {
+		for next = prev.next[i]; next != nil && traversed+prev.span[i] <= rank+1; next = prev.next[i] {
+			traversed += prev.span[i]
+			prev = &next.elementNode
+			if traversed == rank+1 {
+				return next
+			}
+		}
+	}
+	return nil
+}
+
+// RangeByRank calls fn for the elements from position start to stop, both
+// included, until fn returns false. Negative positions count from the end,
+// -1 being the last element.
+func (list *SkipListG[K, V]) RangeByRank(start, stop int, fn func(e *ElementG[K, V]) bool) {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+	if start < 0 {
+		start += list.length
+	}
+	if stop < 0 {
+		stop += list.length
+	}
+	start = max(start, 0)
+	stop = min(stop, list.length-1)
+	e := list.getByRank(start)
+	for i := start; e != nil && i <= stop; i, e = i+1, e.next[0] {
+		if !fn(e) {
+			return
+		}
+	}
+}
+
+// CountInRange returns the number of elements with min <= key <= max.
+func (list *SkipListG[K, V]) CountInRange(min, max K) int {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+	if list.compare(min, max) > 0 {
+		return 0
+	}
+	return list.countBefore(max, true) - list.countBefore(min, false)
+}
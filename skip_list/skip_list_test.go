@@ -2,11 +2,13 @@
  * @Author: zengzh
  * @Date: 2022-12-30 07:53:34
  * @Last Modified by: zengzh
- * @Last Modified time: 2022-12-30 07:53:55
+ * @Last Modified time: 2026-10-19 11:20:45
  */
 package skip_list
 
 import (
+	"cmp"
+	"reflect"
 	"testing"
 )
 
@@ -21,3 +23,122 @@ func TestSkipList(t *testing.T) {
 	}
 }
 
+func keys(list *SkipList) (out []float64) {
+	for e := list.Front(); e != nil; e = e.Next() {
+		out = append(out, e.Key())
+	}
+	return
+}
+
+func TestSkipListIterate(t *testing.T) {
+	lis := NewSkipList()
+	if lis.Front() != nil || lis.Back() != nil {
+		t.Fatal("empty list should have no front or back")
+	}
+	for _, k := range []float64{5, 1, 4, 2, 3} {
+		lis.Set(k, int(k))
+	}
+	if got, want := keys(lis), []float64{1, 2, 3, 4, 5}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys: got %v, want %v", got, want)
+	}
+	var rev []float64
+	for e := lis.Back(); e != nil; e = e.Prev() {
+		rev = append(rev, e.Key())
+	}
+	if want := []float64{5, 4, 3, 2, 1}; !reflect.DeepEqual(rev, want) {
+		t.Fatalf("reverse keys: got %v, want %v", rev, want)
+	}
+
+	lis.Remove(5)
+	lis.Remove(1)
+	lis.Remove(3)
+	if lis.Front().Key() != 2 || lis.Back().Key() != 4 || lis.Back().Prev().Key() != 2 {
+		t.Fatal("links should be fixed after remove")
+	}
+	if lis.Len() != 2 {
+		t.Fatalf("bad len: %v", lis.Len())
+	}
+}
+
+func TestSkipListSeekRange(t *testing.T) {
+	lis := NewSkipList()
+	for i := 0; i < 100; i += 10 {
+		lis.Set(float64(i), i)
+	}
+	if e := lis.Seek(15); e == nil || e.Key() != 20 {
+		t.Fatalf("seek 15 should find 20: %v", e)
+	}
+	if e := lis.Seek(20); e == nil || e.Key() != 20 {
+		t.Fatalf("seek 20 should find 20: %v", e)
+	}
+	if e := lis.Seek(95); e != nil {
+		t.Fatalf("seek past the end should be nil: %v", e.Key())
+	}
+
+	var got []float64
+	lis.Range(20, 50, func(e *Element) bool {
+		got = append(got, e.Key())
+		return true
+	})
+	if want := []float64{20, 30, 40}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("range: got %v, want %v", got, want)
+	}
+	got = nil
+	lis.Range(0, 100, func(e *Element) bool {
+		got = append(got, e.Key())
+		return len(got) < 2
+	})
+	if want := []float64{0, 10}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("range should stop: got %v, want %v", got, want)
+	}
+	got = nil
+	lis.ReverseRange(45, 10, func(e *Element) bool {
+		got = append(got, e.Key())
+		return true
+	})
+	if want := []float64{40, 30, 20}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("reverse range: got %v, want %v", got, want)
+	}
+	got = nil
+	lis.ReverseRange(1000, 75, func(e *Element) bool {
+		got = append(got, e.Key())
+		return true
+	})
+	if want := []float64{90, 80}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("reverse range: got %v, want %v", got, want)
+	}
+}
+
+func TestSkipListGeneric(t *testing.T) {
+	lis := NewOrdered[string, int]()
+	for i, k := range []string{"pear", "apple", "fig", "banana"} {
+		lis.Set(k, i)
+	}
+	var got []string
+	for e := lis.Front(); e != nil; e = e.Next() {
+		got = append(got, e.Key())
+	}
+	if want := []string{"apple", "banana", "fig", "pear"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys: got %v, want %v", got, want)
+	}
+	if e := lis.Get("fig"); e == nil || e.Value() != 2 {
+		t.Fatalf("bad value for fig: %v", e)
+	}
+
+	// descending int64 keys through a comparator
+	desc := NewWithComparator[int64, string](func(a, b int64) int {
+		return cmp.Compare(b, a)
+	})
+	desc.Set(1<<62, "big")
+	desc.Set(1<<62+1, "bigger")
+	desc.Set(-3, "small")
+	if e := desc.Front(); e.Key() != 1<<62+1 || e.Next().Key() != 1<<62 {
+		t.Fatalf("int64 keys should be exact and descending: %v", e.Key())
+	}
+	if e := desc.Seek(0); e == nil || e.Value() != "small" {
+		t.Fatalf("seek should follow the comparator: %v", e)
+	}
+	if desc.Remove(-3) == nil || desc.Len() != 2 {
+		t.Fatalf("bad remove")
+	}
+}
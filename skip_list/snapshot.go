@@ -0,0 +1,276 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-20 09:31:08
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-20 10:26:14
+ */
+package skip_list
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// A snapshot starts with a fixed header:
+//
+//	magic    [4]byte "SKLS"
+//	version  uint16
+//	flags    uint8, snapshotLevels if the level heights are stored
+//	maxLevel uint8
+//	count    uint64, number of elements
+//	crc      uint32, of the fields above
+//
+// followed by blocks of elements in key order, and an empty block at the end:
+//
+//	size  uint32, size of the payload
+//	n     uint32, number of elements in the payload
+//	crc   uint32, of size, n and the payload
+//	payload
+//
+// Each element of a payload is an optional level byte, then the uvarint
+// prefixed key and value written by the codec.
+const (
+	snapshotMagic     = "SKLS"
+	snapshotVersion   = 1
+	snapshotLevels    = 1
+	snapshotHdrSize   = 20
+	snapshotBlockSize = 64 << 10
+)
+
+var (
+	ErrBadSnapshot = errors.New("not a skip list snapshot")
+	ErrChecksum    = errors.New("snapshot checksum mismatch")
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Codec serializes the keys and values of a snapshot.
+type Codec[K, V any] interface {
+	AppendKey(b []byte, key K) ([]byte, error)
+	AppendValue(b []byte, value V) ([]byte, error)
+	DecodeKey(b []byte) (K, error)
+	DecodeValue(b []byte) (V, error)
+}
+
+// GobCodec encodes every key and value with encoding/gob. It works for any
+// type, but a codec written for the types is much more compact.
+type GobCodec[K, V any] struct{}
+
+func gobAppend(b []byte, v any) ([]byte, error) {
+	buf := bytes.NewBuffer(b)
+	err := gob.NewEncoder(buf).Encode(v)
+	return buf.Bytes(), err
+}
+
+func gobDecode[T any](b []byte) (v T, err error) {
+	err = gob.NewDecoder(bytes.NewReader(b)).Decode(&v)
+	return
+}
+
+func (GobCodec[K, V]) AppendKey(b []byte, key K) ([]byte, error) {
+	return gobAppend(b, &key)
+}
+
+func (GobCodec[K, V]) AppendValue(b []byte, value V) ([]byte, error) {
+	return gobAppend(b, &value)
+}
+
+func (GobCodec[K, V]) DecodeKey(b []byte) (K, error) {
+	return gobDecode[K](b)
+}
+
+func (GobCodec[K, V]) DecodeValue(b []byte) (V, error) {
+	return gobDecode[V](b)
+}
+
+// appendField appends a uvarint size and the field written by fn.
+func appendField(b []byte, fn func([]byte) ([]byte, error)) ([]byte, error) {
+	// reserve the longest uvarint, then move the field if it was shorter
+	start := len(b)
+	b = append(b, make([]byte, binary.MaxVarintLen64)...)
+	b, err := fn(b)
+	if err != nil {
+		return b, err
+	}
+	size := len(b) - start - binary.MaxVarintLen64
+	n := binary.PutUvarint(b[start:], uint64(size))
+	copy(b[start+n:], b[start+binary.MaxVarintLen64:])
+	return b[:start+n+size], nil
+}
+
+// WriteSnapshot writes the elements of the list with codec. With levels, the
+// level height of every element is stored too, so the restored list has the
+// same structure.
+func (list *SkipListG[K, V]) WriteSnapshot(w io.Writer, codec Codec[K, V], levels bool) error {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	var hdr [snapshotHdrSize]byte
+	copy(hdr[:4], snapshotMagic)
+	binary.BigEndian.PutUint16(hdr[4:], snapshotVersion)
+	if levels {
+		hdr[6] = snapshotLevels
+	}
+	hdr[7] = byte(list.maxLevel)
+	binary.BigEndian.PutUint64(hdr[8:], uint64(list.length))
+	binary.BigEndian.PutUint32(hdr[16:], crc32.Checksum(hdr[:16], crcTable))
+	if _, err := bw.Write(hdr[:]); err != nil {
+		return err
+	}
+
+	payload := make([]byte, 12, 12+snapshotBlockSize)
+	n := 0
+	var err error
+	for e := list.next[0]; e != nil; e = e.next[0] {
+		if levels {
+			payload = append(payload, byte(len(e.next)))
+		}
+		if payload, err = appendField(payload, func(b []byte) ([]byte, error) {
+			return codec.AppendKey(b, e.key)
+		}); err != nil {
+			return fmt.Errorf("encode key: %w", err)
+		}
+		if payload, err = appendField(payload, func(b []byte) ([]byte, error) {
+			return codec.AppendValue(b, e.value)
+		}); err != nil {
+			return fmt.Errorf("encode value: %w", err)
+		}
+		n++
+		if len(payload) >= 12+snapshotBlockSize {
+			if err = writeBlock(bw, payload, n); err != nil {
+				return err
+			}
+			payload, n = payload[:12], 0
+		}
+	}
+	if n > 0 {
+		if err = writeBlock(bw, payload, n); err != nil {
+			return err
+		}
+	}
+	if err = writeBlock(bw, payload[:12], 0); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// writeBlock fills the 12 byte block header in front of the payload and
+// writes the block.
+func writeBlock(w io.Writer, block []byte, n int) error {
+	binary.BigEndian.PutUint32(block[0:], uint32(len(block)-12))
+	binary.BigEndian.PutUint32(block[4:], uint32(n))
+	crc := crc32.Update(crc32.Checksum(block[:8], crcTable), crcTable, block[12:])
+	binary.BigEndian.PutUint32(block[8:], crc)
+	_, err := w.Write(block)
+	return err
+}
+
+// ReadSnapshot replaces the elements of the list with those of a snapshot
+// written with codec. Stored levels above the max level of the list are
+// capped, without stored levels new ones are drawn. A corrupted or truncated
+// snapshot returns an error and leaves the list unchanged.
+func (list *SkipListG[K, V]) ReadSnapshot(r io.Reader, codec Codec[K, V]) error {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	br := bufio.NewReader(r)
+	var hdr [snapshotHdrSize]byte
+	if _, err := io.ReadFull(br, hdr[:]); err != nil {
+		return ErrBadSnapshot
+	}
+	if string(hdr[:4]) != snapshotMagic {
+		return ErrBadSnapshot
+	}
+	if crc32.Checksum(hdr[:16], crcTable) != binary.BigEndian.Uint32(hdr[16:]) {
+		return fmt.Errorf("header: %w", ErrChecksum)
+	}
+	if v := binary.BigEndian.Uint16(hdr[4:]); v > snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", v)
+	}
+	levels := hdr[6]&snapshotLevels != 0
+	count := binary.BigEndian.Uint64(hdr[8:])
+
+	// build aside, so a bad snapshot leaves the list as it was
+	tmp := newSkipList[K, V](list.maxLevel, list.compare)
+	tmp.randSource = list.randSource
+	tmp.probTabale = list.probTabale
+	lasts := tmp.getLastElementNodes()
+	ranks := tmp.prevRankCache
+
+	var buf bytes.Buffer
+	for block := 0; ; block++ {
+		var bh [12]byte
+		if _, err := io.ReadFull(br, bh[:]); err != nil {
+			return fmt.Errorf("block %d: %w", block, ErrBadSnapshot)
+		}
+		size := binary.BigEndian.Uint32(bh[0:])
+		n := binary.BigEndian.Uint32(bh[4:])
+		// copy rather than allocate size bytes, which may be corrupted
+		buf.Reset()
+		if _, err := io.CopyN(&buf, br, int64(size)); err != nil {
+			return fmt.Errorf("block %d: %w", block, ErrBadSnapshot)
+		}
+		payload := buf.Bytes()
+		if crc32.Update(crc32.Checksum(bh[:8], crcTable), crcTable, payload) != binary.BigEndian.Uint32(bh[8:]) {
+			return fmt.Errorf("block %d: %w", block, ErrChecksum)
+		}
+		if n == 0 {
+			break
+		}
+		for i := uint32(0); i < n; i++ {
+			level := tmp.randLevel()
+			if levels {
+				if len(payload) == 0 || payload[0] == 0 {
+					return fmt.Errorf("block %d: %w", block, ErrBadSnapshot)
+				}
+				level = min(int(payload[0]), tmp.maxLevel)
+				payload = payload[1:]
+			}
+			kb, rest, ok := readField(payload)
+			if !ok {
+				return fmt.Errorf("block %d: %w", block, ErrBadSnapshot)
+			}
+			vb, rest, ok := readField(rest)
+			if !ok {
+				return fmt.Errorf("block %d: %w", block, ErrBadSnapshot)
+			}
+			payload = rest
+			key, err := codec.DecodeKey(kb)
+			if err != nil {
+				return fmt.Errorf("decode key: %w", err)
+			}
+			value, err := codec.DecodeValue(vb)
+			if err != nil {
+				return fmt.Errorf("decode value: %w", err)
+			}
+			if tmp.tail != nil && tmp.compare(key, tmp.tail.key) <= 0 {
+				return fmt.Errorf("block %d: %w", block, ErrNotSorted)
+			}
+			tmp.appendElement(lasts, ranks, key, value, level)
+		}
+		if len(payload) != 0 {
+			return fmt.Errorf("block %d: %w", block, ErrBadSnapshot)
+		}
+	}
+	if uint64(tmp.length) != count {
+		return ErrBadSnapshot
+	}
+	tmp.finishAppend(lasts, ranks)
+	list.takeElements(tmp)
+	return nil
+}
+
+func readField(b []byte) (field, rest []byte, ok bool) {
+	size, n := binary.Uvarint(b)
+	if n <= 0 || uint64(len(b)-n) < size {
+		return nil, nil, false
+	}
+	return b[n : n+int(size)], b[n+int(size):], true
+}
@@ -0,0 +1,154 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-20 10:02:44
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-20 10:26:14
+ */
+package skip_list
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type intStringCodec struct{}
+
+func (intStringCodec) AppendKey(b []byte, key int) ([]byte, error) {
+	return binary.AppendVarint(b, int64(key)), nil
+}
+
+func (intStringCodec) AppendValue(b []byte, value string) ([]byte, error) {
+	return append(b, value...), nil
+}
+
+func (intStringCodec) DecodeKey(b []byte) (int, error) {
+	k, n := binary.Varint(b)
+	if n != len(b) {
+		return 0, errors.New("bad key")
+	}
+	return int(k), nil
+}
+
+func (intStringCodec) DecodeValue(b []byte) (string, error) {
+	return string(b), nil
+}
+
+func levelsOf[K, V any](list *SkipListG[K, V]) (levels []int) {
+	for e := list.next[0]; e != nil; e = e.next[0] {
+		levels = append(levels, len(e.next))
+	}
+	return levels
+}
+
+func snapshotList(n int) *SkipListG[int, string] {
+	lis := NewOrdered[int, string]()
+	for i := 0; i < n; i++ {
+		lis.Set(i*3-n, fmt.Sprint(i))
+	}
+	return lis
+}
+
+func TestSkipListSnapshot(t *testing.T) {
+	// large enough to span several blocks
+	lis := snapshotList(20000)
+	for _, levels := range []bool{true, false} {
+		var buf bytes.Buffer
+		if err := lis.WriteSnapshot(&buf, intStringCodec{}, levels); err != nil {
+			t.Fatal(err)
+		}
+		got := NewOrdered[int, string]()
+		got.Set(1, "replaced")
+		if err := got.ReadSnapshot(&buf, intStringCodec{}); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(checkList(t, got), checkList(t, lis)) {
+			t.Fatalf("levels %v: keys differ", levels)
+		}
+		for e, f := lis.Front(), got.Front(); e != nil; e, f = e.Next(), f.Next() {
+			if e.Value() != f.Value() {
+				t.Fatalf("value of %v: got %v, want %v", e.Key(), f.Value(), e.Value())
+			}
+		}
+		if same := reflect.DeepEqual(levelsOf(got), levelsOf(lis)); same != levels {
+			t.Fatalf("levels %v: same structure %v", levels, same)
+		}
+	}
+}
+
+func TestSkipListSnapshotEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewOrdered[int, string]().WriteSnapshot(&buf, intStringCodec{}, true); err != nil {
+		t.Fatal(err)
+	}
+	lis := snapshotList(10)
+	if err := lis.ReadSnapshot(&buf, intStringCodec{}); err != nil {
+		t.Fatal(err)
+	}
+	if checkList(t, lis) != nil {
+		t.Fatalf("list should be empty")
+	}
+}
+
+func TestSkipListSnapshotGob(t *testing.T) {
+	lis := NewOrdered[string, []int]()
+	for _, k := range strings.Fields("d a c b") {
+		lis.Set(k, []int{len(k), int(k[0])})
+	}
+	var buf bytes.Buffer
+	if err := lis.WriteSnapshot(&buf, GobCodec[string, []int]{}, false); err != nil {
+		t.Fatal(err)
+	}
+	got := NewOrdered[string, []int]()
+	if err := got.ReadSnapshot(&buf, GobCodec[string, []int]{}); err != nil {
+		t.Fatal(err)
+	}
+	if e := got.Get("c"); e == nil || !reflect.DeepEqual(e.Value(), []int{1, 'c'}) {
+		t.Fatalf("c should be restored")
+	}
+	if keys := checkList(t, got); !reflect.DeepEqual(keys, []string{"a", "b", "c", "d"}) {
+		t.Fatalf("got %v", keys)
+	}
+}
+
+func TestSkipListSnapshotCorrupt(t *testing.T) {
+	var buf bytes.Buffer
+	if err := snapshotList(50).WriteSnapshot(&buf, intStringCodec{}, true); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	restore := func(b []byte) error {
+		lis := snapshotList(3)
+		err := lis.ReadSnapshot(bytes.NewReader(b), intStringCodec{})
+		if err != nil && !reflect.DeepEqual(checkList(t, lis), checkList(t, snapshotList(3))) {
+			t.Fatalf("failed restore changed the list")
+		}
+		return err
+	}
+	for i := 0; i < len(data); i++ {
+		b := bytes.Clone(data)
+		b[i] ^= 0x40
+		if err := restore(b); err == nil {
+			t.Fatalf("flipped byte %v not detected", i)
+		}
+	}
+	for i := 0; i < len(data); i++ {
+		if err := restore(data[:i]); err == nil {
+			t.Fatalf("truncation at %v not detected", i)
+		}
+	}
+	// a valid block with unsorted keys
+	lis := NewWithComparator[int, string](func(a, b int) int { return b - a })
+	lis.Set(1, "a")
+	lis.Set(2, "b")
+	buf.Reset()
+	lis.WriteSnapshot(&buf, intStringCodec{}, false)
+	if err := restore(buf.Bytes()); !errors.Is(err, ErrNotSorted) {
+		t.Fatalf("expected ErrNotSorted, got %v", err)
+	}
+}
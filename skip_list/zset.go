@@ -0,0 +1,284 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-19 15:30:41
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-25 11:41:09
+ */
+package skip_list
+
+import (
+	"cmp"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrNaNScore is returned for a score, or the result of an increment, which
+// is not a number: NaN has no place in the order of the scores.
+var ErrNaNScore = errors.New("score is not a number (NaN)")
+
+// zsetKey orders members by score, then lexicographically.
+type zsetKey struct {
+	score  float64
+	member string
+}
+
+func compareZSetKey(a, b zsetKey) int {
+	if c := cmp.Compare(a.score, b.score); c != 0 {
+		return c
+	}
+	return strings.Compare(a.member, b.member)
+}
+
+type ZMember struct {
+	Member string
+	Score  float64
+}
+
+// ScoreBound is an end of a score range, Value may be +/-Inf.
+type ScoreBound struct {
+	Value     float64
+	Exclusive bool
+}
+
+// ParseScoreBound parses a Redis style score bound: "1.5", "(1.5", "-inf"
+// or "+inf".
+func ParseScoreBound(s string) (ScoreBound, error) {
+	var b ScoreBound
+	if strings.HasPrefix(s, "(") {
+		b.Exclusive = true
+		s = s[1:]
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(v) {
+		return b, errors.New("invalid score bound")
+	}
+	b.Value = v
+	return b, nil
+}
+
+func (b ScoreBound) aboveMin(score float64) bool {
+	return score > b.Value || (!b.Exclusive && score == b.Value)
+}
+
+func (b ScoreBound) belowMax(score float64) bool {
+	return score < b.Value || (!b.Exclusive && score == b.Value)
+}
+
+// LexBound is an end of a member range. Inf set to -1 or +1 makes it the
+// smallest or the largest possible member.
+type LexBound struct {
+	Value     string
+	Exclusive bool
+	Inf       int
+}
+
+// ParseLexBound parses a Redis style lex bound: "[a", "(a", "-" or "+".
+func ParseLexBound(s string) (LexBound, error) {
+	switch {
+	case s == "-":
+		return LexBound{Inf: -1}, nil
+	case s == "+":
+		return LexBound{Inf: 1}, nil
+	case strings.HasPrefix(s, "["):
+		return LexBound{Value: s[1:]}, nil
+	case strings.HasPrefix(s, "("):
+		return LexBound{Value: s[1:], Exclusive: true}, nil
+	}
+	return LexBound{}, errors.New("invalid lex bound")
+}
+
+func (b LexBound) aboveMin(member string) bool {
+	if b.Inf != 0 {
+		return b.Inf < 0
+	}
+	c := strings.Compare(member, b.Value)
+	return c > 0 || (!b.Exclusive && c == 0)
+}
+
+func (b LexBound) belowMax(member string) bool {
+	if b.Inf != 0 {
+		return b.Inf > 0
+	}
+	c := strings.Compare(member, b.Value)
+	return c < 0 || (!b.Exclusive && c == 0)
+}
+
+// ZSet is a Redis like sorted set of unique members ordered by score, then
+// by member. The skip list gives the order and the ranks, a map gives the
+// score of a member in O(1).
+type ZSet struct {
+	mutex sync.RWMutex
+	list  *SkipListG[zsetKey, struct{}]
+	dict  map[string]float64
+}
+
+func NewZSet() *ZSet {
+	return &ZSet{
+		list: NewWithComparator[zsetKey, struct{}](compareZSetKey),
+		dict: make(map[string]float64),
+	}
+}
+
+// ZAdd sets the score of member, returns true if member is new.
+func (z *ZSet) ZAdd(score float64, member string) (bool, error) {
+	if math.IsNaN(score) {
+		return false, ErrNaNScore
+	}
+	z.mutex.Lock()
+	defer z.mutex.Unlock()
+	return z.add(score, member), nil
+}
+
+func (z *ZSet) add(score float64, member string) bool {
+	old, ok := z.dict[member]
+	if ok {
+		if old == score {
+			return false
+		}
+		z.list.Remove(zsetKey{old, member})
+	}
+	z.dict[member] = score
+	z.list.Set(zsetKey{score, member}, struct{}{})
+	return !ok
+}
+
+// ZRem removes member, returns true if it was in the set.
+func (z *ZSet) ZRem(member string) bool {
+	z.mutex.Lock()
+	defer z.mutex.Unlock()
+	score, ok := z.dict[member]
+	if !ok {
+		return false
+	}
+	delete(z.dict, member)
+	z.list.Remove(zsetKey{score, member})
+	return true
+}
+
+func (z *ZSet) ZScore(member string) (float64, bool) {
+	z.mutex.RLock()
+	defer z.mutex.RUnlock()
+	score, ok := z.dict[member]
+	return score, ok
+}
+
+// ZIncrBy adds increment to the score of member, a missing member starting
+// at 0, and returns the new score. Adding -Inf to +Inf or the reverse gives
+// no number, the score is left as it was then.
+func (z *ZSet) ZIncrBy(increment float64, member string) (float64, error) {
+	z.mutex.Lock()
+	defer z.mutex.Unlock()
+	score := z.dict[member] + increment
+	if math.IsNaN(score) {
+		return 0, ErrNaNScore
+	}
+	z.add(score, member)
+	return score, nil
+}
+
+func (z *ZSet) ZCard() int {
+	z.mutex.RLock()
+	defer z.mutex.RUnlock()
+	return len(z.dict)
+}
+
+// ZRank returns the 0 based position of member by ascending score.
+func (z *ZSet) ZRank(member string) (int, bool) {
+	z.mutex.RLock()
+	defer z.mutex.RUnlock()
+	score, ok := z.dict[member]
+	if !ok {
+		return 0, false
+	}
+	return z.list.Rank(zsetKey{score, member}), true
+}
+
+// ZRevRank returns the 0 based position of member by descending score.
+func (z *ZSet) ZRevRank(member string) (int, bool) {
+	z.mutex.RLock()
+	defer z.mutex.RUnlock()
+	score, ok := z.dict[member]
+	if !ok {
+		return 0, false
+	}
+	return len(z.dict) - 1 - z.list.Rank(zsetKey{score, member}), true
+}
+
+// ZRange returns the members from position start to stop, both included.
+// Negative positions count from the end.
+func (z *ZSet) ZRange(start, stop int) []ZMember {
+	z.mutex.RLock()
+	defer z.mutex.RUnlock()
+	var out []ZMember
+	z.list.RangeByRank(start, stop, func(e *ElementG[zsetKey, struct{}]) bool {
+		out = append(out, ZMember{e.key.member, e.key.score})
+		return true
+	})
+	return out
+}
+
+// ZRangeByScore returns the members with a score between min and max in
+// ascending order. Like LIMIT in Redis the first offset members are skipped
+// and at most count are returned, a negative count meaning all.
+func (z *ZSet) ZRangeByScore(min, max ScoreBound, offset, count int) []ZMember {
+	z.mutex.RLock()
+	defer z.mutex.RUnlock()
+	var out []ZMember
+	if offset < 0 || count == 0 {
+		return out
+	}
+	// "" is the smallest member, so this is the first key with score >= min
+	e := z.list.Seek(zsetKey{min.Value, ""})
+	for e != nil && !min.aboveMin(e.key.score) {
+		e = e.Next()
+	}
+	for ; e != nil && max.belowMax(e.key.score); e = e.Next() {
+		if offset > 0 {
+			offset--
+			continue
+		}
+		out = append(out, ZMember{e.key.member, e.key.score})
+		if count > 0 && len(out) == count {
+			break
+		}
+	}
+	return out
+}
+
+// ZRangeByLex returns the members between min and max in lexicographical
+// order, with the same offset and count as ZRangeByScore. As in Redis it is
+// meant for sets where every member has the same score, the score of the
+// first member is used.
+func (z *ZSet) ZRangeByLex(min, max LexBound, offset, count int) []ZMember {
+	z.mutex.RLock()
+	defer z.mutex.RUnlock()
+	var out []ZMember
+	first := z.list.Front()
+	if first == nil || offset < 0 || count == 0 {
+		return out
+	}
+	score := first.key.score
+	var e *ElementG[zsetKey, struct{}]
+	if min.Inf < 0 {
+		e = first
+	} else {
+		e = z.list.Seek(zsetKey{score, min.Value})
+	}
+	for e != nil && e.key.score == score && !min.aboveMin(e.key.member) {
+		e = e.Next()
+	}
+	for ; e != nil && e.key.score == score && max.belowMax(e.key.member); e = e.Next() {
+		if offset > 0 {
+			offset--
+			continue
+		}
+		out = append(out, ZMember{e.key.member, e.key.score})
+		if count > 0 && len(out) == count {
+			break
+		}
+	}
+	return out
+}
@@ -0,0 +1,158 @@
+/*
+ * @Author: zengzh
+ * @Date: 2026-10-19 16:10:55
+ * @Last Modified by: zengzh
+ * @Last Modified time: 2026-10-25 11:41:09
+ */
+package skip_list
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func members(ms []ZMember) (out []string) {
+	for _, m := range ms {
+		out = append(out, m.Member)
+	}
+	return
+}
+
+func TestZSet(t *testing.T) {
+	z := NewZSet()
+	add := func(score float64, member string) bool {
+		added, err := z.ZAdd(score, member)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return added
+	}
+	if !add(100, "alice") || !add(80, "bob") || !add(80, "carol") || !add(120, "dave") {
+		t.Fatal("new members should be added")
+	}
+	if add(90, "bob") {
+		t.Fatal("updating a member should not report it as new")
+	}
+	if s, ok := z.ZScore("bob"); !ok || s != 90 {
+		t.Fatalf("bad score for bob: %v", s)
+	}
+	if s, err := z.ZIncrBy(15, "carol"); err != nil || s != 95 {
+		t.Fatalf("bad score for carol: %v", s)
+	}
+	if s, err := z.ZIncrBy(1, "erin"); err != nil || s != 1 || z.ZCard() != 5 {
+		t.Fatalf("ZIncrBy should add missing members: %v", s)
+	}
+	if got, want := members(z.ZRange(0, -1)), []string{"erin", "bob", "carol", "alice", "dave"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("range: got %v, want %v", got, want)
+	}
+	if r, ok := z.ZRank("carol"); !ok || r != 2 {
+		t.Fatalf("bad rank for carol: %v", r)
+	}
+	if r, ok := z.ZRevRank("dave"); !ok || r != 0 {
+		t.Fatalf("bad rev rank for dave: %v", r)
+	}
+	if !z.ZRem("erin") || z.ZRem("erin") {
+		t.Fatal("erin should be removed once")
+	}
+	if _, ok := z.ZRank("erin"); ok {
+		t.Fatal("removed members have no rank")
+	}
+}
+
+func TestZSetNaN(t *testing.T) {
+	z := NewZSet()
+	if _, err := z.ZAdd(math.NaN(), "a"); !errors.Is(err, ErrNaNScore) || z.ZCard() != 0 {
+		t.Fatalf("a NaN score should be rejected: %v", err)
+	}
+	if _, err := z.ZIncrBy(math.Inf(1), "a"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := z.ZIncrBy(math.Inf(-1), "a"); !errors.Is(err, ErrNaNScore) {
+		t.Fatalf("+inf - inf should be rejected: %v", err)
+	}
+	if s, _ := z.ZScore("a"); !math.IsInf(s, 1) {
+		t.Fatalf("the score should be kept: %v", s)
+	}
+	if _, err := z.ZIncrBy(math.NaN(), "b"); !errors.Is(err, ErrNaNScore) || z.ZCard() != 1 {
+		t.Fatalf("a NaN increment should be rejected: %v", err)
+	}
+	z.ZAdd(1, "b")
+	if r, ok := z.ZRank("a"); !ok || r != 1 {
+		t.Fatalf("bad rank for a: %v", r)
+	}
+}
+
+func TestZSetRangeByScore(t *testing.T) {
+	z := NewZSet()
+	for i, m := range []string{"a", "b", "c", "d", "e"} {
+		z.ZAdd(float64(i), m)
+	}
+	z.ZAdd(2, "c2")
+	bound := func(s string) ScoreBound {
+		b, err := ParseScoreBound(s)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return b
+	}
+	cases := []struct {
+		min, max      string
+		offset, count int
+		want          []string
+	}{
+		{"1", "3", 0, -1, []string{"b", "c", "c2", "d"}},
+		{"(1", "(3", 0, -1, []string{"c", "c2"}},
+		{"(2", "+inf", 0, -1, []string{"d", "e"}},
+		{"-inf", "+inf", 1, 2, []string{"b", "c"}},
+		{"-inf", "2", 3, -1, []string{"c2"}},
+		{"3", "1", 0, -1, nil},
+	}
+	for _, c := range cases {
+		got := members(z.ZRangeByScore(bound(c.min), bound(c.max), c.offset, c.count))
+		if !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("[%v %v] limit %v %v: got %v, want %v", c.min, c.max, c.offset, c.count, got, c.want)
+		}
+	}
+	if _, err := ParseScoreBound("(nope"); err == nil {
+		t.Fatal("expected error for a bad bound")
+	}
+	if b := bound("-inf"); !math.IsInf(b.Value, -1) {
+		t.Fatalf("bad -inf bound: %v", b)
+	}
+}
+
+func TestZSetRangeByLex(t *testing.T) {
+	z := NewZSet()
+	for _, m := range []string{"d", "a", "e", "b", "c", "f", "g"} {
+		z.ZAdd(0, m)
+	}
+	bound := func(s string) LexBound {
+		b, err := ParseLexBound(s)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return b
+	}
+	cases := []struct {
+		min, max      string
+		offset, count int
+		want          []string
+	}{
+		{"-", "[c", 0, -1, []string{"a", "b", "c"}},
+		{"-", "(c", 0, -1, []string{"a", "b"}},
+		{"[aaa", "(g", 0, -1, []string{"b", "c", "d", "e", "f"}},
+		{"(e", "+", 0, -1, []string{"f", "g"}},
+		{"-", "+", 2, 3, []string{"c", "d", "e"}},
+	}
+	for _, c := range cases {
+		got := members(z.ZRangeByLex(bound(c.min), bound(c.max), c.offset, c.count))
+		if !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("[%v %v] limit %v %v: got %v, want %v", c.min, c.max, c.offset, c.count, got, c.want)
+		}
+	}
+	if _, err := ParseLexBound("c"); err == nil {
+		t.Fatal("expected error for a bad bound")
+	}
+}